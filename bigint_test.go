@@ -0,0 +1,197 @@
+package gjson_benchmarks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+// corpusID is an integer id in a corpus, along with its exact source text.
+type corpusID struct {
+	keys []string
+	raw  string
+}
+
+func (id corpusID) path() string { return strings.Join(id.keys, ".") }
+
+// collectIDs finds every number in the document that is stored under a key
+// named "id" or ending in "_id".
+func collectIDs(json string) []corpusID {
+	var ids []corpusID
+	var walk func(value gjson.Result, keys []string)
+	walk = func(value gjson.Result, keys []string) {
+		value.ForEach(func(key, child gjson.Result) bool {
+			k := key.String()
+			if value.IsArray() {
+				k = strconv.Itoa(int(key.Int()))
+			}
+			ckeys := append(keys[:len(keys):len(keys)], k)
+			switch {
+			case child.IsObject(), child.IsArray():
+				walk(child, ckeys)
+			case child.Type == gjson.Number && !value.IsArray() &&
+				(k == "id" || strings.HasSuffix(k, "_id")):
+				ids = append(ids, corpusID{ckeys, child.Raw})
+			}
+			return true
+		})
+	}
+	walk(gjson.Parse(json), nil)
+	return ids
+}
+
+var twitterIDs = collectIDs(twitterLarge)
+
+// idReader reads every id, returning each one formatted as a decimal.
+// Readers that go through float64 are not exact, and are only reported.
+type idReader struct {
+	name  string
+	exact bool
+	read  func(data []byte, ids []corpusID, out []string) []string
+}
+
+func lookupValue(v interface{}, keys []string) interface{} {
+	for _, key := range keys {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			v = vv[key]
+		case []interface{}:
+			i, _ := arrayIndex(key)
+			if i >= len(vv) {
+				return nil
+			}
+			v = vv[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+var idReaders = []idReader{
+	{"gjson/Int", true, func(data []byte, ids []corpusID, out []string) []string {
+		for _, id := range ids {
+			out = append(out, strconv.FormatInt(gjson.GetBytes(data, id.path()).Int(), 10))
+		}
+		return out
+	}},
+	{"gjson/Uint", true, func(data []byte, ids []corpusID, out []string) []string {
+		for _, id := range ids {
+			out = append(out, strconv.FormatUint(gjson.GetBytes(data, id.path()).Uint(), 10))
+		}
+		return out
+	}},
+	{"gjson/Float", false, func(data []byte, ids []corpusID, out []string) []string {
+		for _, id := range ids {
+			f := gjson.GetBytes(data, id.path()).Float()
+			out = append(out, strconv.FormatFloat(f, 'f', -1, 64))
+		}
+		return out
+	}},
+	{"jsonparser/GetInt", true, func(data []byte, ids []corpusID, out []string) []string {
+		for _, id := range ids {
+			n, _ := jsonparser.GetInt(data, jsonparserKeys(id.path())...)
+			out = append(out, strconv.FormatInt(n, 10))
+		}
+		return out
+	}},
+	{"json/UseNumber", true, func(data []byte, ids []corpusID, out []string) []string {
+		var v interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return out
+		}
+		for _, id := range ids {
+			n, _ := lookupValue(v, id.keys).(json.Number)
+			out = append(out, n.String())
+		}
+		return out
+	}},
+	{"json/float64", false, func(data []byte, ids []corpusID, out []string) []string {
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return out
+		}
+		for _, id := range ids {
+			f, _ := lookupValue(v, id.keys).(float64)
+			out = append(out, strconv.FormatFloat(f, 'f', -1, 64))
+		}
+		return out
+	}},
+	{"jsoniter/ReadInt64", true, func(data []byte, ids []corpusID, out []string) []string {
+		iter := jsoniter.ConfigDefault.BorrowIterator(nil)
+		defer jsoniter.ConfigDefault.ReturnIterator(iter)
+		for _, id := range ids {
+			iter.ResetBytes(data)
+			iter.Error = nil
+			for _, key := range id.keys {
+				if !jsoniterChild(iter, key) {
+					break
+				}
+			}
+			out = append(out, strconv.FormatInt(iter.ReadInt64(), 10))
+		}
+		return out
+	}},
+}
+
+// countExact returns how many ids were read back exactly, and the first
+// one that was not.
+func countExact(ids []corpusID, out []string) (int, string) {
+	var exact int
+	var first string
+	for i, id := range ids {
+		if i < len(out) && out[i] == id.raw {
+			exact++
+		} else if first == "" {
+			got := "nothing"
+			if i < len(out) {
+				got = out[i]
+			}
+			first = fmt.Sprintf("%s: %s != %s", id.path(), got, id.raw)
+		}
+	}
+	return exact, first
+}
+
+func TestBigIntPrecision(t *testing.T) {
+	if len(twitterIDs) == 0 {
+		t.Fatal("no ids found in the corpus")
+	}
+	data := []byte(twitterLarge)
+	tbl := newTable(fmt.Sprintf("Exact integer ids (%d ids in twitterLarge)", len(twitterIDs)),
+		"reader", "exact", "all exact", "first loss")
+	for _, r := range idReaders {
+		exact, first := countExact(twitterIDs, r.read(data, twitterIDs, nil))
+		if r.exact && exact != len(twitterIDs) {
+			t.Errorf("%s: %d of %d ids exact, first loss %s", r.name, exact, len(twitterIDs), first)
+		}
+		tbl.add(r.name, strconv.Itoa(exact), yesno(exact == len(twitterIDs)), first)
+	}
+	publish(t, tbl)
+}
+
+func BenchmarkBigInt(b *testing.B) {
+	data := []byte(twitterLarge)
+	for _, r := range idReaders {
+		b.Run(r.name, func(b *testing.B) {
+			var out []string
+			b.ReportAllocs()
+			stopMetrics := reportMetrics(b)
+			for i := 0; i < b.N; i++ {
+				out = r.read(data, twitterIDs, out[:0])
+			}
+			stopMetrics()
+			exact, _ := countExact(twitterIDs, out)
+			b.ReportMetric(float64(exact)/float64(len(twitterIDs)), "exact")
+		})
+	}
+}
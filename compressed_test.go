@@ -0,0 +1,129 @@
+package gjson_benchmarks
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+// codec is a compression format that payloads arrive in.
+type codec struct {
+	name     string
+	compress func(w io.Writer) io.WriteCloser
+	reader   func(r io.Reader) (io.ReadCloser, error)
+}
+
+var codecs = []codec{
+	{"gzip",
+		func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }},
+	{"zlib",
+		func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		func(r io.Reader) (io.ReadCloser, error) { return zlib.NewReader(r) }},
+	{"flate",
+		func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+		func(r io.Reader) (io.ReadCloser, error) { return flate.NewReader(r), nil }},
+}
+
+var (
+	compressedMu sync.Mutex
+	compressed   = map[string][]byte{}
+)
+
+// compressedCorpus returns json compressed with c, compressing it on
+// first use.
+func compressedCorpus(c codec, name, json string) []byte {
+	compressedMu.Lock()
+	defer compressedMu.Unlock()
+	key := c.name + "/" + name
+	if data, ok := compressed[key]; ok {
+		return data
+	}
+	var buf bytes.Buffer
+	w := c.compress(&buf)
+	if _, err := io.WriteString(w, json); err != nil {
+		panic(err)
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	compressed[key] = buf.Bytes()
+	return compressed[key]
+}
+
+func decompress(c codec, data []byte) ([]byte, error) {
+	r, err := c.reader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func TestCompressedRoundTrip(t *testing.T) {
+	for _, cc := range convertCases {
+		for _, c := range codecs {
+			data, err := decompress(c, compressedCorpus(c, cc.name, cc.json))
+			if err != nil {
+				t.Fatalf("%s: %s: %v", cc.name, c.name, err)
+			}
+			if string(data) != cc.json {
+				t.Fatalf("%s: %s: corpus changed", cc.name, c.name)
+			}
+		}
+	}
+}
+
+// BenchmarkCompressed decompresses each corpus and extracts a value with
+// every library, reporting the time spent in each half.
+func BenchmarkCompressed(b *testing.B) {
+	for _, cc := range convertCases {
+		b.Run(cc.name, func(b *testing.B) {
+			for _, c := range codecs {
+				data := compressedCorpus(c, cc.name, cc.json)
+				b.Run(c.name, func(b *testing.B) {
+					for _, lib := range libraries {
+						b.Run(lib.name, func(b *testing.B) {
+							want, err := lib.get([]byte(cc.json), cc.path)
+							if err != nil {
+								b.Skip(err)
+							}
+							var decompressTime, parseTime time.Duration
+							b.SetBytes(int64(len(cc.json)))
+							b.ReportAllocs()
+							b.ResetTimer()
+							stopMetrics := reportMetrics(b)
+							for i := 0; i < b.N; i++ {
+								start := time.Now()
+								json, err := decompress(c, data)
+								if err != nil {
+									b.Fatal(err)
+								}
+								mid := time.Now()
+								got, err := lib.get(json, cc.path)
+								if err != nil || !bytes.Equal(got, want) {
+									b.Fatalf("got %q, %v", got, err)
+								}
+								decompressTime += mid.Sub(start)
+								parseTime += time.Since(mid)
+							}
+							stopMetrics()
+							b.ReportMetric(float64(decompressTime)/float64(b.N), "decompress-ns/op")
+							b.ReportMetric(float64(parseTime)/float64(b.N), "parse-ns/op")
+							b.ReportMetric(float64(len(cc.json))/float64(len(data)), "ratio")
+						})
+					}
+				})
+			}
+		})
+	}
+}
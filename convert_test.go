@@ -0,0 +1,226 @@
+package gjson_benchmarks
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/buger/jsonparser"
+	"github.com/tidwall/gjson"
+)
+
+// bytesToString returns a string that shares memory with b. The bytes
+// must not change while the string is in use.
+func bytesToString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// stringToBytes returns a slice that shares memory with s. The slice must
+// never be written to.
+func stringToBytes(s string) []byte {
+	var b []byte
+	sh := (*reflect.StringHeader)(unsafe.Pointer(&s))
+	bh := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	bh.Data, bh.Len, bh.Cap = sh.Data, sh.Len, sh.Len
+	return b
+}
+
+// aliases reports whether res points into data.
+func aliases(res, data []byte) bool {
+	if len(res) == 0 || len(data) == 0 {
+		return false
+	}
+	start := uintptr(unsafe.Pointer(&data[0]))
+	p := uintptr(unsafe.Pointer(&res[0]))
+	return p >= start && p < start+uintptr(len(data))
+}
+
+var convertCases = []struct {
+	name, json, path string
+}{
+	{"massive", massiveJSON, "50.widget.text.onMouseUp"},
+	{"example", exampleJSON, "widget.text.onMouseUp"},
+	{"basic", basicJSON, "loggy.programmers.1.firstName"},
+	{"twitterMedium", twitterMedium, "statuses.3.user.screen_name"},
+	{"twitterLarge", twitterLarge, "statuses.50.user.screen_name"},
+}
+
+// stringAPIs are the libraries that can search a string directly.
+var stringAPIs = map[string]func(json, path string) string{
+	"gjson": func(json, path string) string { return gjson.Get(json, path).Raw },
+}
+
+// resultGetters return each library's result without normalizing it, so
+// that a subslice of the input stays a subslice.
+var resultGetters = func() []library {
+	getters := []library{
+		{name: "gjson/GetBytes", get: func(data []byte, path string) ([]byte, error) {
+			return stringToBytes(gjson.GetBytes(data, path).Raw), nil
+		}},
+		{name: "gjson/unsafe", get: func(data []byte, path string) ([]byte, error) {
+			return stringToBytes(gjson.Get(bytesToString(data), path).Raw), nil
+		}},
+		{name: "jsonparser", get: func(data []byte, path string) ([]byte, error) {
+			value, _, _, err := jsonparser.Get(data, jsonparserKeys(path)...)
+			return value, err
+		}},
+	}
+	for _, lib := range libraries {
+		if lib.name != "gjson" && lib.name != "jsonparser" {
+			getters = append(getters, lib)
+		}
+	}
+	return getters
+}()
+
+// directGetter returns a search of path with the library's own API, with
+// the path split beforehand. The libraries wrappers normalize their
+// results, and the copies that takes would hide the conversion costs.
+func directGetter(name, path string) func(data []byte) error {
+	switch name {
+	case "gjson":
+		return func(data []byte) error {
+			gjson.GetBytes(data, path)
+			return nil
+		}
+	case "encoding/json":
+		keys := pathKeys(path)
+		return func(data []byte) error {
+			_, err := stdjsonFind(data, keys)
+			return err
+		}
+	case "ffjson":
+		keys := pathKeys(path)
+		return func(data []byte) error {
+			_, err := ffjsonGetKeys(data, keys)
+			return err
+		}
+	case "easyjson":
+		keys := pathKeys(path)
+		return func(data []byte) error {
+			_, err := easyjsonGetKeys(data, keys)
+			return err
+		}
+	case "jsonparser":
+		keys := jsonparserKeys(path)
+		return func(data []byte) error {
+			_, _, _, err := jsonparser.Get(data, keys...)
+			return err
+		}
+	case "jsoniter":
+		keys := pathKeys(path)
+		return func(data []byte) error {
+			_, err := jsoniterGetKeys(data, keys)
+			return err
+		}
+	}
+	panic("no direct getter for " + name)
+}
+
+// skipUnreadable skips libraries that cannot read the corpus at all, such
+// as the strict decoders on the malformed basic corpus.
+func skipUnreadable(b *testing.B, get func() error) {
+	if err := get(); err != nil {
+		b.Skip(err)
+	}
+}
+
+// BenchmarkConvertNone searches each corpus held in the type that the
+// library takes, so no conversion is needed.
+func BenchmarkConvertNone(b *testing.B) {
+	for _, c := range convertCases {
+		json, data, path := c.json, []byte(c.json), c.path
+		b.Run(c.name, func(b *testing.B) {
+			for _, lib := range libraries {
+				dget := directGetter(lib.name, path)
+				get := func() error { return dget(data) }
+				if sget, ok := stringAPIs[lib.name]; ok {
+					get = func() error { sget(json, path); return nil }
+				}
+				b.Run(lib.name, func(b *testing.B) {
+					skipUnreadable(b, get)
+					b.ReportAllocs()
+					b.ResetTimer()
+					defer reportMetrics(b)()
+					for i := 0; i < b.N; i++ {
+						get()
+					}
+				})
+			}
+		})
+	}
+}
+
+// BenchmarkConvertGet searches each corpus held in the other type, so it
+// has to be converted on every call, either by copying or unsafely.
+func BenchmarkConvertGet(b *testing.B) {
+	for _, c := range convertCases {
+		json, data, path := c.json, []byte(c.json), c.path
+		b.Run(c.name, func(b *testing.B) {
+			for _, lib := range libraries {
+				dget := directGetter(lib.name, path)
+				copied := func() error { return dget([]byte(json)) }
+				viaUnsafe := func() error { return dget(stringToBytes(json)) }
+				if sget, ok := stringAPIs[lib.name]; ok {
+					copied = func() error { sget(string(data), path); return nil }
+					viaUnsafe = func() error { sget(bytesToString(data), path); return nil }
+				}
+				for _, mode := range []struct {
+					name string
+					get  func() error
+				}{{"copy", copied}, {"unsafe", viaUnsafe}} {
+					b.Run(lib.name+"/"+mode.name, func(b *testing.B) {
+						skipUnreadable(b, mode.get)
+						b.ReportAllocs()
+						b.ResetTimer()
+						defer reportMetrics(b)()
+						for i := 0; i < b.N; i++ {
+							mode.get()
+						}
+					})
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkConvertGetBytes searches []byte input and compares keeping
+// the result as returned, which may alias the input, with making sure the
+// caller owns it. The aliased metric is 1 when the result points into the
+// input.
+func BenchmarkConvertGetBytes(b *testing.B) {
+	for _, c := range convertCases {
+		data, path := []byte(c.json), c.path
+		b.Run(c.name, func(b *testing.B) {
+			for _, lib := range resultGetters {
+				res, err := lib.get(data, path)
+				aliased := 0.0
+				if aliases(res, data) {
+					aliased = 1
+				}
+				for _, owned := range []bool{false, true} {
+					name := lib.name + "/returned"
+					if owned {
+						name = lib.name + "/owned"
+					}
+					b.Run(name, func(b *testing.B) {
+						if err != nil {
+							b.Skip(err)
+						}
+						b.ReportAllocs()
+						b.ResetTimer()
+						defer reportMetrics(b)()
+						for i := 0; i < b.N; i++ {
+							res, _ := lib.get(data, path)
+							if owned && aliased == 1 {
+								res = append([]byte(nil), res...)
+							}
+							_ = res
+						}
+						b.ReportMetric(aliased, "aliased")
+					})
+				}
+			}
+		})
+	}
+}
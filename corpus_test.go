@@ -0,0 +1,23 @@
+package gjson_benchmarks
+
+// corpus is a named JSON document that benchmarks and reports run against.
+type corpus struct {
+	name string
+	json string
+}
+
+var corpora = []corpus{
+	{"example", exampleJSON},
+	{"basic", basicJSON},
+	{"twitterMedium", twitterMedium},
+	{"twitterLarge", twitterLarge},
+}
+
+func lookupCorpus(name string) corpus {
+	for _, c := range corpora {
+		if c.name == name {
+			return c
+		}
+	}
+	panic("unknown corpus: " + name)
+}
@@ -0,0 +1,234 @@
+package gjson_benchmarks
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+// queryManyCount is how many paths are asked of one document.
+const queryManyCount = 300
+
+// breakEvenCount is how many of the paths the break-even report times.
+// Any.Get takes milliseconds per query on twitterLarge, so timing them all
+// would overrun the time budget many times over.
+const breakEvenCount = 20
+
+// docPath is a path in both gjson syntax and as jsoniter Get arguments.
+type docPath struct {
+	path  string
+	parts []interface{}
+}
+
+// walkValues calls fn for every value below v, outermost first.
+func walkValues(v gjson.Result, parts []interface{}, fn func(parts []interface{}, v gjson.Result)) {
+	if !v.IsObject() && !v.IsArray() {
+		return
+	}
+	i := 0
+	v.ForEach(func(key, value gjson.Result) bool {
+		var part interface{} = i
+		if v.IsObject() {
+			part = key.Str
+		}
+		i++
+		child := append(parts[:len(parts):len(parts)], part)
+		fn(child, value)
+		walkValues(value, child, fn)
+		return true
+	})
+}
+
+// escapePathKey escapes the characters that gjson reads as path syntax.
+func escapePathKey(key string) string {
+	if !strings.ContainsAny(key, `.*?|#@!=<>%\`) {
+		return key
+	}
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(`.*?|#@!=<>%\`, key[i]) >= 0 {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(key[i])
+	}
+	return sb.String()
+}
+
+func joinPath(parts []interface{}) string {
+	var sb strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		switch part := part.(type) {
+		case int:
+			sb.WriteString(strconv.Itoa(part))
+		case string:
+			sb.WriteString(escapePathKey(part))
+		}
+	}
+	return sb.String()
+}
+
+// docPaths returns up to n paths of json, spread evenly over the document.
+func docPaths(json string, n int) []docPath {
+	var all []docPath
+	walkValues(gjson.Parse(json), nil, func(parts []interface{}, _ gjson.Result) {
+		all = append(all, docPath{joinPath(parts), parts})
+	})
+	if len(all) <= n {
+		return all
+	}
+	paths := make([]docPath, n)
+	for i := range paths {
+		paths[i] = all[i*len(all)/n]
+	}
+	return paths
+}
+
+// docIndex records where every value of a document lives after a single
+// scan, so later lookups by plain path need no parsing.
+type docIndex struct {
+	data  []byte
+	spans map[string][2]int
+}
+
+func newDocIndex(data []byte) *docIndex {
+	ix := &docIndex{data: data, spans: map[string][2]int{}}
+	walkValues(gjson.ParseBytes(data), nil, func(parts []interface{}, v gjson.Result) {
+		// Like gjson, the first of duplicate keys wins.
+		path := joinPath(parts)
+		if _, ok := ix.spans[path]; !ok {
+			ix.spans[path] = [2]int{v.Index, v.Index + len(v.Raw)}
+		}
+	})
+	return ix
+}
+
+// get returns the raw value at path. Only plain paths are supported; no
+// wildcards, queries or modifiers.
+func (ix *docIndex) get(path string) ([]byte, bool) {
+	span, ok := ix.spans[path]
+	if !ok {
+		return nil, false
+	}
+	return ix.data[span[0]:span[1]], true
+}
+
+// queryManyImpls answer every path of one document. Each run includes
+// whatever parse or scan the approach needs up front.
+var queryManyImpls = []struct {
+	name string
+	run  func(data []byte, paths []docPath, fn func(i int, raw string))
+}{
+	{"gjson/Get", func(data []byte, paths []docPath, fn func(int, string)) {
+		json := string(data)
+		for i, p := range paths {
+			fn(i, gjson.Get(json, p.path).Raw)
+		}
+	}},
+	{"gjson/Parse", func(data []byte, paths []docPath, fn func(int, string)) {
+		doc := gjson.ParseBytes(data)
+		for i, p := range paths {
+			fn(i, doc.Get(p.path).Raw)
+		}
+	}},
+	{"jsoniter/Any", func(data []byte, paths []docPath, fn func(int, string)) {
+		doc := jsoniter.Get(data)
+		for i, p := range paths {
+			fn(i, doc.Get(p.parts...).ToString())
+		}
+	}},
+	{"index", func(data []byte, paths []docPath, fn func(int, string)) {
+		ix := newDocIndex(data)
+		for i, p := range paths {
+			raw, _ := ix.get(p.path)
+			fn(i, string(raw))
+		}
+	}},
+}
+
+func TestQueryMany(t *testing.T) {
+	for _, c := range corpora {
+		data := []byte(c.json)
+		paths := docPaths(c.json, queryManyCount)
+		for _, impl := range queryManyImpls {
+			if !gjson.Valid(c.json) && !strings.HasPrefix(impl.name, "gjson/") {
+				// The basic corpus holds a malformed member, where the
+				// answer depends on how far each parser reads.
+				continue
+			}
+			impl.run(data, paths, func(i int, raw string) {
+				want := gjson.Get(c.json, paths[i].path)
+				if impl.name == "jsoniter/Any" {
+					// ToString unescapes strings and gives "" for null.
+					switch want.Type {
+					case gjson.String:
+						want.Raw = want.Str
+					case gjson.Null:
+						want.Raw = ""
+					}
+				}
+				if raw != want.Raw {
+					t.Fatalf("%s: %s: %s: got %.40q, want %.40q",
+						c.name, impl.name, paths[i].path, raw, want.Raw)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkQueryMany(b *testing.B) {
+	for _, c := range corpora {
+		data := []byte(c.json)
+		paths := docPaths(c.json, queryManyCount)
+		b.Run(c.name, func(b *testing.B) {
+			for _, impl := range queryManyImpls {
+				b.Run(impl.name, func(b *testing.B) {
+					var n int
+					b.ReportAllocs()
+					b.ResetTimer()
+					defer reportMetrics(b)()
+					for i := 0; i < b.N; i++ {
+						impl.run(data, paths, func(int, string) { n++ })
+					}
+					b.ReportMetric(float64(len(paths)), "paths/op")
+				})
+			}
+		})
+	}
+}
+
+func TestDocIndexBreakEven(t *testing.T) {
+	if testing.Short() {
+		t.Skip("times hundreds of queries per corpus")
+	}
+	tbl := newTable("Queries before a document index pays for itself",
+		"corpus", "gjson.Get", "Parse+Get", "Any.Get", "build", "lookup", "break-even")
+	for _, c := range corpora {
+		data := []byte(c.json)
+		paths := docPaths(c.json, breakEvenCount)
+		perQuery := func(f func(p docPath)) sample {
+			return timePerOp(20*time.Millisecond, func() {
+				for _, p := range paths {
+					f(p)
+				}
+			}).per(len(paths))
+		}
+		doc := gjson.Parse(c.json)
+		anyDoc := jsoniter.Get(data)
+		var ix *docIndex
+		build := timePerOp(20*time.Millisecond, func() { ix = newDocIndex(data) })
+		get := perQuery(func(p docPath) { gjson.Get(c.json, p.path) })
+		lookup := perQuery(func(p docPath) { ix.get(p.path) })
+		tbl.add(c.name, tbl.timing(get),
+			tbl.timing(perQuery(func(p docPath) { doc.Get(p.path) })),
+			tbl.timing(perQuery(func(p docPath) { anyDoc.Get(p.parts...) })),
+			tbl.timing(build), tbl.timing(lookup), breakEven(build.mean, lookup.mean, get.mean))
+	}
+	publish(t, tbl)
+}
@@ -0,0 +1,244 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+// geoCorpus is a GeoJSON feature collection of polygons, along with every
+// coordinate in document order as parsed by strconv.ParseFloat.
+type geoCorpus struct {
+	json   string
+	floats []float64
+}
+
+var (
+	geoOnce sync.Once
+	geo     geoCorpus
+)
+
+// geoJSON generates the corpus on first use, as it is about 8MB.
+func geoJSON() geoCorpus {
+	geoOnce.Do(func() {
+		geo = makeGeoJSON(2000, 100)
+	})
+	return geo
+}
+
+func makeGeoJSON(features, points int) geoCorpus {
+	rng := rand.New(rand.NewSource(1))
+	var sb strings.Builder
+	var floats []float64
+	num := func(f float64) {
+		var s string
+		switch rng.Intn(4) {
+		case 0:
+			s = strconv.FormatFloat(f, 'f', 6, 64)
+		case 1:
+			s = strconv.FormatFloat(f, 'e', -1, 64)
+		default:
+			s = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			panic(err)
+		}
+		floats = append(floats, v)
+		sb.WriteString(s)
+	}
+	sb.WriteString(`{"type":"FeatureCollection","features":[`)
+	for i := 0; i < features; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `{"type":"Feature","properties":{"id":%d},`, i)
+		sb.WriteString(`"geometry":{"type":"Polygon","coordinates":[[`)
+		for j := 0; j < points; j++ {
+			if j > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('[')
+			num(rng.Float64()*360 - 180)
+			sb.WriteByte(',')
+			num(rng.Float64()*180 - 90)
+			sb.WriteByte(']')
+		}
+		sb.WriteString(`]]}}`)
+	}
+	sb.WriteString(`]}`)
+	return geoCorpus{sb.String(), floats}
+}
+
+type geoFeatures struct {
+	Features []struct {
+		Geometry struct {
+			Coordinates [][][]float64 `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// floatReaders append every coordinate in the document to out.
+var floatReaders = []struct {
+	name string
+	read func(data []byte, out []float64) ([]float64, error)
+}{
+	{"gjson/ForEach", func(data []byte, out []float64) ([]float64, error) {
+		var each func(_, value gjson.Result) bool
+		each = func(_, value gjson.Result) bool {
+			if value.IsArray() {
+				value.ForEach(each)
+			} else {
+				out = append(out, value.Float())
+			}
+			return true
+		}
+		gjson.GetBytes(data, "features").ForEach(func(_, feature gjson.Result) bool {
+			feature.Get("geometry.coordinates").ForEach(each)
+			return true
+		})
+		return out, nil
+	}},
+	{"jsonparser/ArrayEach", func(data []byte, out []float64) ([]float64, error) {
+		var ferr error
+		var each func(value []byte, typ jsonparser.ValueType, _ int, err error)
+		each = func(value []byte, typ jsonparser.ValueType, _ int, err error) {
+			if typ == jsonparser.Array {
+				_, err = jsonparser.ArrayEach(value, each)
+			} else {
+				var f float64
+				f, err = jsonparser.ParseFloat(value)
+				out = append(out, f)
+			}
+			if err != nil && ferr == nil {
+				ferr = err
+			}
+		}
+		_, err := jsonparser.ArrayEach(data,
+			func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+				jsonparser.ArrayEach(value, each, "geometry", "coordinates")
+			}, "features")
+		if err == nil {
+			err = ferr
+		}
+		return out, err
+	}},
+	{"jsoniter/ReadFloat64", func(data []byte, out []float64) ([]float64, error) {
+		iter := jsoniter.ConfigDefault.BorrowIterator(data)
+		defer jsoniter.ConfigDefault.ReturnIterator(iter)
+		var read func()
+		read = func() {
+			for iter.ReadArray() {
+				if iter.WhatIsNext() == jsoniter.ArrayValue {
+					read()
+				} else {
+					out = append(out, iter.ReadFloat64())
+				}
+			}
+		}
+		if !jsoniterChild(iter, "features") {
+			return out, iter.Error
+		}
+		for iter.ReadArray() {
+			for key := iter.ReadObject(); key != ""; key = iter.ReadObject() {
+				if key != "geometry" {
+					iter.Skip()
+					continue
+				}
+				if jsoniterChild(iter, "coordinates") {
+					read()
+					// skip the rest of the geometry
+					for key := iter.ReadObject(); key != ""; key = iter.ReadObject() {
+						iter.Skip()
+					}
+				}
+			}
+		}
+		return out, iter.Error
+	}},
+	{"json/Struct", func(data []byte, out []float64) ([]float64, error) {
+		var v geoFeatures
+		if err := json.Unmarshal(data, &v); err != nil {
+			return out, err
+		}
+		for _, f := range v.Features {
+			for _, ring := range f.Geometry.Coordinates {
+				for _, point := range ring {
+					out = append(out, point...)
+				}
+			}
+		}
+		return out, nil
+	}},
+}
+
+// countBitExact returns how many floats have the same bits as the
+// strconv.ParseFloat result, and describes the first one that does not.
+func countBitExact(want, got []float64) (int, string) {
+	var exact int
+	var first string
+	for i := range want {
+		if i < len(got) && math.Float64bits(got[i]) == math.Float64bits(want[i]) {
+			exact++
+		} else if first == "" {
+			if i < len(got) {
+				first = fmt.Sprintf("#%d: %v != %v", i, got[i], want[i])
+			} else {
+				first = fmt.Sprintf("#%d: missing", i)
+			}
+		}
+	}
+	return exact, first
+}
+
+func TestFloatBitExact(t *testing.T) {
+	g := geoJSON()
+	data := []byte(g.json)
+	tbl := newTable(fmt.Sprintf("Bit-exact floats (%d coordinates)", len(g.floats)),
+		"reader", "read", "bit exact", "first difference")
+	for _, r := range floatReaders {
+		out, err := r.read(data, nil)
+		if err != nil {
+			t.Errorf("%s: %v", r.name, err)
+		}
+		exact, first := countBitExact(g.floats, out)
+		if len(out) != len(g.floats) || exact != len(g.floats) {
+			t.Errorf("%s: read %d, %d of %d bit exact, first difference %s",
+				r.name, len(out), exact, len(g.floats), first)
+		}
+		tbl.add(r.name, strconv.Itoa(len(out)), strconv.Itoa(exact), first)
+	}
+	publish(t, tbl)
+}
+
+func BenchmarkFloats(b *testing.B) {
+	g := geoJSON()
+	data := []byte(g.json)
+	for _, r := range floatReaders {
+		b.Run(r.name, func(b *testing.B) {
+			var out []float64
+			var err error
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			stopMetrics := reportMetrics(b)
+			for i := 0; i < b.N; i++ {
+				if out, err = r.read(data, out[:0]); err != nil {
+					b.Fatal(err)
+				}
+			}
+			stopMetrics()
+			exact, _ := countBitExact(g.floats, out)
+			b.ReportMetric(float64(exact)/float64(len(g.floats)), "exact")
+		})
+	}
+}
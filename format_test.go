@@ -0,0 +1,327 @@
+package gjson_benchmarks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/pretty"
+)
+
+type formatter struct {
+	name   string
+	format func(src []byte) ([]byte, error)
+	exact  bool // must reproduce the expected bytes of a valid document
+}
+
+// formatJob is one way of formatting a document. The expected output keeps
+// strings and numbers exactly as they are in the source, so re-marshalling
+// libraries, which re-escape strings, are not expected to match.
+type formatJob struct {
+	name       string
+	expected   func(src []byte) ([]byte, error) // nil when there is no reference
+	formatters []formatter
+}
+
+func stdIndent(indent string) func(src []byte) ([]byte, error) {
+	return func(src []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		err := json.Indent(&buf, src, "", indent)
+		return buf.Bytes(), err
+	}
+}
+
+func stdCompact(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	err := json.Compact(&buf, src)
+	return buf.Bytes(), err
+}
+
+func jsoniterRemarshal(api jsoniter.API, indent string) func(src []byte) ([]byte, error) {
+	return func(src []byte) ([]byte, error) {
+		var v interface{}
+		if err := api.Unmarshal(src, &v); err != nil {
+			return nil, err
+		}
+		if indent == "" {
+			return api.Marshal(v)
+		}
+		return api.MarshalIndent(v, "", indent)
+	}
+}
+
+func stdRemarshalIndent(src []byte) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(src, &v); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func prettyOptions(opts *pretty.Options) func(src []byte) ([]byte, error) {
+	return func(src []byte) ([]byte, error) {
+		return pretty.PrettyOptions(src, opts), nil
+	}
+}
+
+// sortedRaw compacts the document with the keys of every object sorted,
+// keeping all other values byte for byte.
+func sortedRaw(src []byte) ([]byte, error) {
+	src = bytes.TrimSpace(src)
+	if len(src) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	var buf bytes.Buffer
+	switch src[0] {
+	case '{':
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(src, &m); err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			value, err := sortedRaw(m[key])
+			if err != nil {
+				return nil, err
+			}
+			k, _ := json.Marshal(key)
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	case '[':
+		var a []json.RawMessage
+		if err := json.Unmarshal(src, &a); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('[')
+		for i, elem := range a {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			value, err := sortedRaw(elem)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(value)
+		}
+		buf.WriteByte(']')
+	default:
+		return stdCompact(src)
+	}
+	return buf.Bytes(), nil
+}
+
+// packedIndent indents with two spaces like json.Indent, except that
+// arrays without objects in them go on one line, with ", " between the
+// elements, when the line stays within width columns.
+func packedIndent(width int) func(src []byte) ([]byte, error) {
+	return func(src []byte) ([]byte, error) {
+		if !gjson.ValidBytes(src) {
+			return nil, fmt.Errorf("invalid json")
+		}
+		return appendPacked(nil, gjson.ParseBytes(src), 0, width), nil
+	}
+}
+
+func appendPacked(buf []byte, v gjson.Result, depth, width int) []byte {
+	if v.IsArray() {
+		if line, ok := oneLine(v); ok {
+			// the columns left after the newline, as pretty counts them
+			nl := bytes.LastIndexByte(buf, '\n')
+			if nl < 0 {
+				nl = 0
+			}
+			if left := width - (len(buf) - nl); left > 3 && len(line) <= left {
+				return append(buf, line...)
+			}
+		}
+	}
+	if !v.IsArray() && !v.IsObject() {
+		return append(buf, v.Raw...)
+	}
+	open, close := byte('['), byte(']')
+	if v.IsObject() {
+		open, close = '{', '}'
+	}
+	buf = append(buf, open)
+	n := 0
+	v.ForEach(func(key, value gjson.Result) bool {
+		if n > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '\n')
+		buf = append(buf, strings.Repeat("  ", depth+1)...)
+		if open == '{' {
+			buf = append(buf, key.Raw...)
+			buf = append(buf, ':', ' ')
+		}
+		buf = appendPacked(buf, value, depth+1, width)
+		n++
+		return true
+	})
+	if n > 0 {
+		buf = append(buf, '\n')
+		buf = append(buf, strings.Repeat("  ", depth)...)
+	}
+	return append(buf, close)
+}
+
+// oneLine returns the value written on one line, or false when it holds
+// an object.
+func oneLine(v gjson.Result) (string, bool) {
+	if v.IsObject() {
+		return "", false
+	}
+	if !v.IsArray() {
+		return v.Raw, true
+	}
+	var elems []string
+	ok := true
+	v.ForEach(func(_, elem gjson.Result) bool {
+		var s string
+		s, ok = oneLine(elem)
+		elems = append(elems, s)
+		return ok
+	})
+	return "[" + strings.Join(elems, ", ") + "]", ok
+}
+
+var formatJobs = []formatJob{
+	{"ugly", stdCompact, []formatter{
+		{"pretty", func(src []byte) ([]byte, error) { return pretty.Ugly(src), nil }, true},
+		{"encoding/json", stdCompact, true},
+		{"jsoniter", jsoniterRemarshal(jsoniter.ConfigDefault, ""), false},
+	}},
+	{"indent", stdIndent("  "), []formatter{
+		{"pretty", prettyOptions(&pretty.Options{Indent: "  "}), true},
+		{"encoding/json", stdIndent("  "), true},
+		{"jsoniter", jsoniterRemarshal(jsoniter.ConfigDefault, "  "), false},
+	}},
+	{"indent/tab", stdIndent("\t"), []formatter{
+		{"pretty", prettyOptions(&pretty.Options{Indent: "\t"}), true},
+		{"encoding/json", stdIndent("\t"), true},
+		// jsoniter panics on any indent other than spaces
+	}},
+	{"indent/sorted", func(src []byte) ([]byte, error) {
+		sorted, err := sortedRaw(src)
+		if err != nil {
+			return nil, err
+		}
+		return stdIndent("  ")(sorted)
+	}, []formatter{
+		{"pretty", prettyOptions(&pretty.Options{Indent: "  ", SortKeys: true}), true},
+		{"encoding/json", stdRemarshalIndent, false},
+		{"jsoniter", jsoniterRemarshal(jsoniter.ConfigCompatibleWithStandardLibrary, "  "), false},
+	}},
+	// Packing short arrays onto one line has no stdlib equivalent, so
+	// packedIndent is the reference.
+	{"width80", packedIndent(80), []formatter{
+		{"pretty", prettyOptions(pretty.DefaultOptions), true},
+	}},
+	{"width20", packedIndent(20), []formatter{
+		{"pretty", prettyOptions(&pretty.Options{Width: 20, Indent: "  "}), true},
+	}},
+}
+
+// formatMatch compares the output with the expected formatting, ignoring
+// the trailing newline that pretty always adds. It returns "yes", "no"
+// along with the offset of the first difference, or "n/a".
+func formatMatch(out, expected []byte) string {
+	if expected == nil {
+		return "n/a"
+	}
+	out = bytes.TrimSuffix(out, []byte("\n"))
+	if bytes.Equal(out, expected) {
+		return "yes"
+	}
+	i := 0
+	for i < len(out) && i < len(expected) && out[i] == expected[i] {
+		i++
+	}
+	return fmt.Sprintf("no (byte %d)", i)
+}
+
+func TestFormatOutput(t *testing.T) {
+	tbl := newTable("Formatting output against the expected bytes",
+		"job", "corpus", "formatter", "match")
+	for _, job := range formatJobs {
+		for _, c := range corpora {
+			src := bytes.TrimSpace([]byte(c.json))
+			var expected []byte
+			if job.expected != nil {
+				var err error
+				if expected, err = job.expected(src); err != nil {
+					// no reference formatting for malformed input
+					expected = nil
+				}
+			}
+			for _, f := range job.formatters {
+				out, err := f.format(src)
+				match := formatMatch(out, expected)
+				if err != nil {
+					match = "error"
+				}
+				if f.exact && expected != nil && match != "yes" {
+					t.Errorf("%s with %s on %s: %s", job.name, f.name, c.name, match)
+				}
+				tbl.add(job.name, c.name, f.name, match)
+			}
+		}
+	}
+	publish(t, tbl)
+}
+
+func BenchmarkFormat(b *testing.B) {
+	for _, job := range formatJobs {
+		b.Run(job.name, func(b *testing.B) {
+			for _, c := range corpora {
+				src := bytes.TrimSpace([]byte(c.json))
+				var expected []byte
+				if job.expected != nil {
+					expected, _ = job.expected(src)
+				}
+				b.Run(c.name, func(b *testing.B) {
+					for _, f := range job.formatters {
+						b.Run(f.name, func(b *testing.B) {
+							if _, err := f.format(src); err != nil {
+								b.Skipf("%s rejects %s: %v", f.name, c.name, err)
+							}
+							var out []byte
+							b.SetBytes(int64(len(src)))
+							b.ReportAllocs()
+							b.ResetTimer()
+							stopMetrics := reportMetrics(b)
+							for i := 0; i < b.N; i++ {
+								out, _ = f.format(src)
+							}
+							stopMetrics()
+							if expected != nil {
+								// 1 when the output is byte for byte as expected
+								if formatMatch(out, expected) == "yes" {
+									b.ReportMetric(1, "match")
+								} else {
+									b.ReportMetric(0, "match")
+								}
+							}
+						})
+					}
+				})
+			}
+		})
+	}
+}
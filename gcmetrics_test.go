@@ -0,0 +1,107 @@
+package gjson_benchmarks
+
+import (
+	"math"
+	"runtime/metrics"
+	"testing"
+	"time"
+)
+
+const (
+	gcCyclesMetric   = "/gc/cycles/total:gc-cycles"
+	heapObjectMetric = "/memory/classes/heap/objects:bytes"
+)
+
+// gcPauseMetric is the stop-the-world pause histogram. Newer runtimes
+// deprecate /gc/pauses in favor of the /sched one.
+var gcPauseMetric = func() string {
+	name := "/gc/pauses:seconds"
+	for _, d := range metrics.All() {
+		if d.Name == "/sched/pauses/total/gc:seconds" {
+			name = d.Name
+		}
+	}
+	return name
+}()
+
+type gcSample struct {
+	cycles uint64
+	pause  float64
+	heap   uint64
+}
+
+func gcSamples() []metrics.Sample {
+	return []metrics.Sample{
+		{Name: gcCyclesMetric},
+		{Name: gcPauseMetric},
+		{Name: heapObjectMetric},
+	}
+}
+
+// readGC reads the samples from gcSamples. Reading into the same samples
+// again reuses the histogram instead of allocating a new one.
+func readGC(samples []metrics.Sample) gcSample {
+	metrics.Read(samples)
+	var s gcSample
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		s.cycles = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindFloat64Histogram {
+		s.pause = histogramSum(samples[1].Value.Float64Histogram())
+	}
+	if samples[2].Value.Kind() == metrics.KindUint64 {
+		s.heap = samples[2].Value.Uint64()
+	}
+	return s
+}
+
+// histogramSum estimates the total of a runtime histogram from the
+// middle of each bucket.
+func histogramSum(h *metrics.Float64Histogram) float64 {
+	var sum float64
+	for i, n := range h.Counts {
+		lo, hi := h.Buckets[i], h.Buckets[i+1]
+		switch {
+		case math.IsInf(lo, -1):
+			lo = hi
+		case math.IsInf(hi, 1):
+			hi = lo
+		}
+		sum += float64(n) * (lo + hi) / 2
+	}
+	return sum
+}
+
+// reportMetrics samples the runtime's GC metrics and returns a func that
+// reports what changed since, per op, along with the variation across
+// -count repetitions. Call it right after ResetTimer:
+//
+//	defer reportMetrics(b)()
+//
+// The samples allocate, so the timer is stopped around them, and the
+// returned func leaves it stopped. Benchmarks that check their output
+// after the loop call the returned func in place of StopTimer.
+func reportMetrics(b *testing.B) func() {
+	b.StopTimer()
+	samples := gcSamples()
+	var start gcSample
+	var began time.Time
+	startN := b.N
+	report := func() {
+		elapsed := time.Since(began)
+		b.StopTimer()
+		if b.N == 0 {
+			return
+		}
+		end := readGC(samples)
+		n := float64(b.N)
+		b.ReportMetric(float64(end.cycles-start.cycles)*1000/n, "gc/1kop")
+		b.ReportMetric((end.pause-start.pause)*1e9/n, "stw-ns/op")
+		b.ReportMetric((float64(end.heap)-float64(start.heap))/n, "heap-growth-B/op")
+		noteRun(b, startN, float64(elapsed)/n)
+	}
+	start = readGC(samples)
+	b.StartTimer()
+	began = time.Now()
+	return report
+}
@@ -78,6 +78,7 @@ var benchManyPaths = []string{
 func BenchmarkGJSONGet(t *testing.B) {
 	t.ReportAllocs()
 	t.ResetTimer()
+	defer reportMetrics(t)()
 	for i := 0; i < t.N; i++ {
 		for j := 0; j < len(benchPaths); j++ {
 			if gjson.Get(exampleJSON, benchPaths[j]).Type == gjson.Null {
@@ -91,6 +92,7 @@ func BenchmarkGJSONGet(t *testing.B) {
 func BenchmarkGJSONUnmarshalMap(t *testing.B) {
 	t.ReportAllocs()
 	t.ResetTimer()
+	defer reportMetrics(t)()
 	for i := 0; i < t.N; i++ {
 		for j := 0; j < len(benchPaths); j++ {
 			parts := strings.Split(benchPaths[j], ".")
@@ -147,6 +149,7 @@ func BenchmarkGJSONUnmarshalMap(t *testing.B) {
 func BenchmarkJSONUnmarshalMap(t *testing.B) {
 	t.ReportAllocs()
 	t.ResetTimer()
+	defer reportMetrics(t)()
 	for i := 0; i < t.N; i++ {
 		for j := 0; j < len(benchPaths); j++ {
 			parts := strings.Split(benchPaths[j], ".")
@@ -178,6 +181,7 @@ func BenchmarkJSONUnmarshalMap(t *testing.B) {
 func BenchmarkJSONUnmarshalStruct(t *testing.B) {
 	t.ReportAllocs()
 	t.ResetTimer()
+	defer reportMetrics(t)()
 	for i := 0; i < t.N; i++ {
 		for j := 0; j < len(benchPaths); j++ {
 			var s BenchStruct
@@ -206,6 +210,7 @@ func BenchmarkJSONUnmarshalStruct(t *testing.B) {
 func BenchmarkJSONDecoder(t *testing.B) {
 	t.ReportAllocs()
 	t.ResetTimer()
+	defer reportMetrics(t)()
 	for i := 0; i < t.N; i++ {
 		for j := 0; j < len(benchPaths); j++ {
 			dec := json.NewDecoder(bytes.NewBuffer([]byte(exampleJSON)))
@@ -252,6 +257,7 @@ func BenchmarkJSONDecoder(t *testing.B) {
 func BenchmarkFFJSONLexer(t *testing.B) {
 	t.ReportAllocs()
 	t.ResetTimer()
+	defer reportMetrics(t)()
 	for i := 0; i < t.N; i++ {
 		for j := 0; j < len(benchPaths); j++ {
 			l := fflib.NewFFLexer([]byte(exampleJSON))
@@ -374,6 +380,7 @@ func easyJSONWidget(t *testing.B, l *jlexer.Lexer, j int) {
 func BenchmarkEasyJSONLexer(t *testing.B) {
 	t.ReportAllocs()
 	t.ResetTimer()
+	defer reportMetrics(t)()
 	for i := 0; i < t.N; i++ {
 		for j := 0; j < len(benchPaths); j++ {
 			l := &jlexer.Lexer{Data: []byte(exampleJSON)}
@@ -394,6 +401,7 @@ func BenchmarkJSONParserGet(t *testing.B) {
 	}
 	t.ReportAllocs()
 	t.ResetTimer()
+	defer reportMetrics(t)()
 	for i := 0; i < t.N; i++ {
 		for j, k := range keys {
 			if j == 1 {
@@ -506,6 +514,7 @@ func jsoniterWidget(t *testing.B, iter *jsoniter.Iterator, j int) {
 func BenchmarkJSONIterator(t *testing.B) {
 	t.ReportAllocs()
 	t.ResetTimer()
+	defer reportMetrics(t)()
 	for i := 0; i < t.N; i++ {
 		for j := 0; j < len(benchPaths); j++ {
 			iter := jsoniter.ParseString(jsoniter.ConfigDefault, exampleJSON)
@@ -528,31 +537,6 @@ var massiveJSON = func() string {
 	return buf.String()
 }()
 
-func BenchmarkConvertNone(t *testing.B) {
-	json := massiveJSON
-	t.ReportAllocs()
-	t.ResetTimer()
-	for i := 0; i < t.N; i++ {
-		gjson.Get(json, "50.widget.text.onMouseUp")
-	}
-}
-func BenchmarkConvertGet(t *testing.B) {
-	data := []byte(massiveJSON)
-	t.ReportAllocs()
-	t.ResetTimer()
-	for i := 0; i < t.N; i++ {
-		gjson.Get(string(data), "50.widget.text.onMouseUp")
-	}
-}
-func BenchmarkConvertGetBytes(t *testing.B) {
-	data := []byte(massiveJSON)
-	t.ReportAllocs()
-	t.ResetTimer()
-	for i := 0; i < t.N; i++ {
-		gjson.GetBytes(data, "50.widget.text.onMouseUp")
-	}
-}
-
 var twitterMedium = `{
 	"statuses": [
 	  {
@@ -1017,18 +1001,21 @@ var twitterLarge = func() string {
 func BenchmarkGetComplexPath(b *testing.B) {
 	b.Run("small", func(b *testing.B) {
 		b.ReportAllocs()
+		defer reportMetrics(b)()
 		for i := 0; i < b.N; i++ {
 			_ = gjson.Get(basicJSON, `loggy.programmers.#[tag="good"]#.firstName`)
 		}
 	})
 	b.Run("medium", func(b *testing.B) {
 		b.ReportAllocs()
+		defer reportMetrics(b)()
 		for i := 0; i < b.N; i++ {
 			_ = gjson.Get(twitterMedium, `statuses.#[friends_count>100]#.id`)
 		}
 	})
 	b.Run("large", func(b *testing.B) {
 		b.ReportAllocs()
+		defer reportMetrics(b)()
 		for i := 0; i < b.N; i++ {
 			_ = gjson.Get(twitterLarge, `statuses.#[friends_count>100]#.id`)
 		}
@@ -1038,18 +1025,21 @@ func BenchmarkGetComplexPath(b *testing.B) {
 func BenchmarkGetSimplePath(b *testing.B) {
 	b.Run("small", func(b *testing.B) {
 		b.ReportAllocs()
+		defer reportMetrics(b)()
 		for i := 0; i < b.N; i++ {
 			_ = gjson.Get(basicJSON, `loggy.programmers.0.firstName`)
 		}
 	})
 	b.Run("medium", func(b *testing.B) {
 		b.ReportAllocs()
+		defer reportMetrics(b)()
 		for i := 0; i < b.N; i++ {
 			_ = gjson.Get(twitterMedium, `statuses.3.id`)
 		}
 	})
 	b.Run("large", func(b *testing.B) {
 		b.ReportAllocs()
+		defer reportMetrics(b)()
 		for i := 0; i < b.N; i++ {
 			x := gjson.Get(twitterLarge, `statuses.50.id`)
 			if !x.Exists() {
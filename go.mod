@@ -8,6 +8,7 @@ require (
 	github.com/mailru/easyjson v0.7.7
 	github.com/pquerna/ffjson v0.0.0-20190930134022-aa0246cd15f7
 	github.com/tidwall/gjson v1.18.0
+	github.com/tidwall/pretty v1.2.1
 )
 
 require (
@@ -15,5 +16,4 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
-	github.com/tidwall/pretty v1.2.1 // indirect
 )
@@ -0,0 +1,267 @@
+package gjson_benchmarks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+var errStop = errors.New("stop")
+
+type statusIDs struct {
+	Statuses []struct {
+		IDStr string `json:"id_str"`
+	} `json:"statuses"`
+}
+
+// statusWalkers each read "id_str" from every element of the "statuses"
+// array, appending them to ids.
+var statusWalkers = []struct {
+	name string
+	walk func(data []byte, ids []string) ([]string, error)
+}{
+	{"gjson/ForEach", func(data []byte, ids []string) ([]string, error) {
+		gjson.GetBytes(data, "statuses").ForEach(
+			func(_, value gjson.Result) bool {
+				ids = append(ids, value.Get("id_str").String())
+				return true
+			})
+		return ids, nil
+	}},
+	{"gjson/Array", func(data []byte, ids []string) ([]string, error) {
+		for _, value := range gjson.GetBytes(data, "statuses").Array() {
+			ids = append(ids, value.Get("id_str").String())
+		}
+		return ids, nil
+	}},
+	{"gjson/Path", func(data []byte, ids []string) ([]string, error) {
+		gjson.GetBytes(data, "statuses.#.id_str").ForEach(
+			func(_, value gjson.Result) bool {
+				ids = append(ids, value.String())
+				return true
+			})
+		return ids, nil
+	}},
+	{"jsonparser/ArrayEach", func(data []byte, ids []string) ([]string, error) {
+		var werr error
+		_, err := jsonparser.ArrayEach(data,
+			func(value []byte, _ jsonparser.ValueType, _ int, err error) {
+				s, err := jsonparser.GetString(value, "id_str")
+				if err != nil && werr == nil {
+					werr = err
+				}
+				ids = append(ids, s)
+			}, "statuses")
+		if err == nil {
+			err = werr
+		}
+		return ids, err
+	}},
+	{"jsonparser/ObjectEach", func(data []byte, ids []string) ([]string, error) {
+		var werr error
+		_, err := jsonparser.ArrayEach(data,
+			func(value []byte, _ jsonparser.ValueType, _ int, err error) {
+				err = jsonparser.ObjectEach(value,
+					func(key, value []byte, _ jsonparser.ValueType, _ int) error {
+						if string(key) != "id_str" {
+							return nil
+						}
+						s, err := jsonparser.ParseString(value)
+						if err != nil {
+							return err
+						}
+						ids = append(ids, s)
+						return errStop
+					})
+				if err != errStop && werr == nil {
+					werr = err
+				}
+			}, "statuses")
+		if err == nil {
+			err = werr
+		}
+		return ids, err
+	}},
+	{"jsoniter/ReadArray", func(data []byte, ids []string) ([]string, error) {
+		iter := jsoniter.ConfigDefault.BorrowIterator(data)
+		defer jsoniter.ConfigDefault.ReturnIterator(iter)
+		for key := iter.ReadObject(); key != ""; key = iter.ReadObject() {
+			if key != "statuses" {
+				iter.Skip()
+				continue
+			}
+			for iter.ReadArray() {
+				for key := iter.ReadObject(); key != ""; key = iter.ReadObject() {
+					if key == "id_str" {
+						ids = append(ids, iter.ReadString())
+					} else {
+						iter.Skip()
+					}
+				}
+			}
+		}
+		return ids, iter.Error
+	}},
+	{"json/Decoder", decoderStatusIDs},
+	{"json/Struct", func(data []byte, ids []string) ([]string, error) {
+		var v statusIDs
+		if err := json.Unmarshal(data, &v); err != nil {
+			return ids, err
+		}
+		for _, s := range v.Statuses {
+			ids = append(ids, s.IDStr)
+		}
+		return ids, nil
+	}},
+	{"jsoniter/Struct", func(data []byte, ids []string) ([]string, error) {
+		var v statusIDs
+		if err := jsoniter.Unmarshal(data, &v); err != nil {
+			return ids, err
+		}
+		for _, s := range v.Statuses {
+			ids = append(ids, s.IDStr)
+		}
+		return ids, nil
+	}},
+}
+
+// decoderStatusIDs streams tokens with json.Decoder, without decoding any
+// value other than the ids.
+func decoderStatusIDs(data []byte, ids []string) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := expectDelim(dec, '{'); err != nil {
+		return ids, err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return ids, err
+		}
+		if key != "statuses" {
+			if err := skipTokens(dec); err != nil {
+				return ids, err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return ids, err
+		}
+		for dec.More() {
+			if err := expectDelim(dec, '{'); err != nil {
+				return ids, err
+			}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return ids, err
+				}
+				if key != "id_str" {
+					if err := skipTokens(dec); err != nil {
+						return ids, err
+					}
+					continue
+				}
+				tok, err := dec.Token()
+				if err != nil {
+					return ids, err
+				}
+				s, _ := tok.(string)
+				ids = append(ids, s)
+			}
+			if err := expectDelim(dec, '}'); err != nil {
+				return ids, err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return ids, err
+		}
+	}
+	return ids, nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// skipTokens skips the next value, including any nested values.
+func skipTokens(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func checkStatusIDs(ids []string) error {
+	want := gjson.Get(twitterLarge, "statuses.#.id_str").Array()
+	if len(ids) != len(want) {
+		return fmt.Errorf("got %d ids, expected %d", len(ids), len(want))
+	}
+	for i := range ids {
+		if ids[i] != want[i].String() {
+			return fmt.Errorf("id %d: got %q, expected %q", i, ids[i], want[i].String())
+		}
+	}
+	return nil
+}
+
+func TestIterateStatuses(t *testing.T) {
+	data := []byte(twitterLarge)
+	for _, w := range statusWalkers {
+		ids, err := w.walk(data, nil)
+		if err == nil {
+			err = checkStatusIDs(ids)
+		}
+		if err != nil {
+			t.Errorf("%s: %v", w.name, err)
+		}
+	}
+}
+
+func BenchmarkIterateStatuses(b *testing.B) {
+	data := []byte(twitterLarge)
+	for _, w := range statusWalkers {
+		b.Run(w.name, func(b *testing.B) {
+			var ids []string
+			var err error
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			stopMetrics := reportMetrics(b)
+			for i := 0; i < b.N; i++ {
+				ids, err = w.walk(data, ids[:0])
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			stopMetrics()
+			if err := checkStatusIDs(ids); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package gjson_benchmarks
+
+import (
+	"math/bits"
+	"testing"
+	"time"
+)
+
+// histSubBits is the number of significant bits each histogram bucket
+// keeps, which bounds the relative error of a recorded value to 1/64.
+const histSubBits = 7
+
+// histogram is an HDR-style latency histogram: buckets are linear below
+// 2^histSubBits and log-linear above it.
+type histogram struct {
+	counts []int64
+	total  int64
+	max    int64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]int64, (65-histSubBits)<<(histSubBits-1))}
+}
+
+func histIndex(v int64) int {
+	if v < 1<<histSubBits {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - histSubBits
+	return shift<<(histSubBits-1) + int(v>>shift)
+}
+
+// histValue is the largest value that falls into bucket i.
+func histValue(i int) int64 {
+	if i < 1<<histSubBits {
+		return int64(i)
+	}
+	half := 1 << (histSubBits - 1)
+	shift := i/half - 1
+	top := int64(i%half + half)
+	return (top+1)<<shift - 1
+}
+
+func (h *histogram) record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	h.counts[histIndex(v)]++
+	h.total++
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// quantile returns the latency that q of the recorded values are at or
+// below.
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(q*float64(h.total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, n := range h.counts {
+		seen += n
+		if seen >= rank {
+			if v := histValue(i); v < h.max {
+				return time.Duration(v)
+			}
+			break
+		}
+	}
+	return time.Duration(h.max)
+}
+
+var latencyQuantiles = []struct {
+	name string
+	q    float64
+}{
+	{"p50", 0.50},
+	{"p90", 0.90},
+	{"p99", 0.99},
+	{"p99.9", 0.999},
+}
+
+// latencyOps are each library's get and decode of a corpus.
+var latencyOps = []struct {
+	name string
+	run  func(lib library, data []byte, path string) error
+}{
+	{"get", func(lib library, data []byte, path string) error {
+		_, err := lib.get(data, path)
+		return err
+	}},
+	{"decode", func(lib library, data []byte, _ string) error {
+		_, err := lib.decode(data)
+		return err
+	}},
+}
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram()
+	for v := 1; v <= 100000; v++ {
+		h.record(time.Duration(v))
+	}
+	for _, q := range latencyQuantiles {
+		want := q.q * 100000
+		got := float64(h.quantile(q.q))
+		if got < want || got > want*(1+1.0/64) {
+			t.Fatalf("%s: got %v, want %v within 1/64", q.name, got, want)
+		}
+	}
+	if h.quantile(1) != 100000 {
+		t.Fatalf("max: got %v", h.quantile(1))
+	}
+}
+
+// latencyTime is how long each operation is recorded for, split over the
+// repetitions.
+const latencyTime = 100 * time.Millisecond
+
+func TestLatencyPercentiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("times every operation of every library on every corpus")
+	}
+	header := []string{"library", "corpus", "op"}
+	for _, q := range latencyQuantiles {
+		header = append(header, q.name)
+	}
+	tbl := newTable("Latency percentiles per operation", append(header, "max")...)
+	reps := repCount()
+	for _, lib := range libraries {
+		for _, c := range convertCases {
+			data := []byte(c.json)
+			for _, op := range latencyOps {
+				if err := op.run(lib, data, c.path); err != nil {
+					continue
+				}
+				warmup(latencyTime, func() { op.run(lib, data, c.path) })
+				// one histogram per repetition, so that every percentile
+				// has its own variation
+				values := make([][]float64, len(latencyQuantiles)+1)
+				for r := 0; r < reps; r++ {
+					h := newHistogram()
+					for start := time.Now(); time.Since(start) < latencyTime/time.Duration(reps); {
+						opStart := time.Now()
+						op.run(lib, data, c.path)
+						h.record(time.Since(opStart))
+					}
+					for i, q := range latencyQuantiles {
+						values[i] = append(values[i], float64(h.quantile(q.q)))
+					}
+					values[len(latencyQuantiles)] = append(values[len(latencyQuantiles)], float64(h.max))
+				}
+				row := []string{lib.name, c.name, op.name}
+				for _, xs := range values {
+					mean, cv := meanCV(xs)
+					row = append(row, tbl.timing(sample{time.Duration(mean), cv}))
+				}
+				tbl.add(row...)
+			}
+		}
+	}
+	publish(t, tbl)
+}
+
+// BenchmarkLatency times every operation on its own and reports the
+// percentiles next to the mean.
+func BenchmarkLatency(b *testing.B) {
+	for _, c := range convertCases {
+		data := []byte(c.json)
+		b.Run(c.name, func(b *testing.B) {
+			for _, lib := range libraries {
+				for _, op := range latencyOps {
+					b.Run(lib.name+"/"+op.name, func(b *testing.B) {
+						skipUnreadable(b, func() error { return op.run(lib, data, c.path) })
+						h := newHistogram()
+						b.ReportAllocs()
+						b.ResetTimer()
+						defer reportMetrics(b)()
+						for i := 0; i < b.N; i++ {
+							start := time.Now()
+							op.run(lib, data, c.path)
+							h.record(time.Since(start))
+						}
+						for _, q := range latencyQuantiles {
+							b.ReportMetric(float64(h.quantile(q.q)), q.name+"-ns")
+						}
+						b.ReportMetric(float64(h.max), "max-ns")
+					})
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,469 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mailru/easyjson/jlexer"
+	fflib "github.com/pquerna/ffjson/fflib/v1"
+	"github.com/tidwall/gjson"
+)
+
+var errNotFound = errors.New("not found")
+
+// library is a uniform wrapper around each JSON library, used by the
+// reports and by benchmarks that run the same job against every library.
+type library struct {
+	name string
+	// get returns the raw JSON of the value at a dot path, eg. "a.b.0.c".
+	get func(data []byte, path string) ([]byte, error)
+	// decode decodes the whole document into generic Go values.
+	decode func(data []byte) (interface{}, error)
+}
+
+var libraries = []library{
+	{"gjson", gjsonGet, gjsonDecode},
+	{"encoding/json", stdjsonGet, stdjsonDecode},
+	{"ffjson", ffjsonGet, ffjsonDecode},
+	{"easyjson", easyjsonGet, easyjsonDecode},
+	{"jsonparser", jsonparserGet, jsonparserDecode},
+	{"jsoniter", jsoniterGet, jsoniterDecode},
+}
+
+func pathKeys(path string) []string {
+	return strings.Split(path, ".")
+}
+
+func arrayIndex(key string) (int, bool) {
+	n, err := strconv.Atoi(key)
+	return n, err == nil && n >= 0
+}
+
+func gjsonGet(data []byte, path string) ([]byte, error) {
+	res := gjson.GetBytes(data, path)
+	if !res.Exists() {
+		return nil, errNotFound
+	}
+	return []byte(res.Raw), nil
+}
+
+func gjsonDecode(data []byte) (interface{}, error) {
+	return gjson.ParseBytes(data).Value(), nil
+}
+
+func stdjsonGet(data []byte, path string) ([]byte, error) {
+	v, err := stdjsonFind(data, pathKeys(path))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// stdjsonFind decodes data and returns the value at keys.
+func stdjsonFind(data []byte, keys []string) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = vv[key]; !ok {
+				return nil, errNotFound
+			}
+		case []interface{}:
+			i, ok := arrayIndex(key)
+			if !ok || i >= len(vv) {
+				return nil, errNotFound
+			}
+			v = vv[i]
+		default:
+			return nil, errNotFound
+		}
+	}
+	return v, nil
+}
+
+func stdjsonDecode(data []byte) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+func ffjsonErr(l *fflib.FFLexer) error {
+	if l.BigError != nil {
+		return l.BigError
+	}
+	if err := l.Error.ToError(); err != nil {
+		return err
+	}
+	return errors.New("ffjson: syntax error")
+}
+
+func ffjsonGet(data []byte, path string) ([]byte, error) {
+	return ffjsonGetKeys(data, pathKeys(path))
+}
+
+func ffjsonGetKeys(data []byte, keys []string) ([]byte, error) {
+	l := fflib.NewFFLexer(data)
+	tok := l.Scan()
+	for _, key := range keys {
+		var err error
+		if tok, err = ffjsonChild(l, tok, key); err != nil {
+			return nil, err
+		}
+	}
+	return l.CaptureField(tok)
+}
+
+// ffjsonChild moves the lexer to the value of key in the object or array
+// that starts with tok, and returns the first token of that value.
+func ffjsonChild(l *fflib.FFLexer, tok fflib.FFTok, key string) (fflib.FFTok, error) {
+	switch tok {
+	case fflib.FFTok_left_bracket: // '{'
+		for {
+			tok = l.Scan()
+			if tok == fflib.FFTok_right_bracket {
+				return tok, errNotFound
+			}
+			if tok != fflib.FFTok_string {
+				return tok, ffjsonErr(l)
+			}
+			b, err := l.CaptureField(tok)
+			if err != nil {
+				return tok, err
+			}
+			var name string
+			if err := json.Unmarshal(b, &name); err != nil {
+				return tok, err
+			}
+			if l.Scan() != fflib.FFTok_colon {
+				return tok, ffjsonErr(l)
+			}
+			tok = l.Scan()
+			if name == key {
+				return tok, nil
+			}
+			if err := l.SkipField(tok); err != nil {
+				return tok, err
+			}
+			switch l.Scan() {
+			case fflib.FFTok_comma:
+			case fflib.FFTok_right_bracket:
+				return tok, errNotFound
+			default:
+				return tok, ffjsonErr(l)
+			}
+		}
+	case fflib.FFTok_left_brace: // '['
+		idx, ok := arrayIndex(key)
+		if !ok {
+			return tok, errNotFound
+		}
+		for i := 0; ; i++ {
+			tok = l.Scan()
+			if tok == fflib.FFTok_right_brace {
+				return tok, errNotFound
+			}
+			if i == idx {
+				return tok, nil
+			}
+			if err := l.SkipField(tok); err != nil {
+				return tok, err
+			}
+			switch l.Scan() {
+			case fflib.FFTok_comma:
+			case fflib.FFTok_right_brace:
+				return tok, errNotFound
+			default:
+				return tok, ffjsonErr(l)
+			}
+		}
+	case fflib.FFTok_error, fflib.FFTok_eof:
+		return tok, ffjsonErr(l)
+	}
+	return tok, errNotFound
+}
+
+// ffjsonDecode builds generic values from the ffjson token stream. The
+// ffjson.Unmarshal function only falls back to encoding/json for types
+// without generated code, so the lexer is the only ffjson-native path.
+func ffjsonDecode(data []byte) (interface{}, error) {
+	l := fflib.NewFFLexer(data)
+	v, err := ffjsonValue(l, l.Scan())
+	if err != nil {
+		return v, err
+	}
+	if tok := l.Scan(); tok != fflib.FFTok_eof {
+		return v, fmt.Errorf("ffjson: unexpected trailing token %v", tok)
+	}
+	return v, nil
+}
+
+func ffjsonValue(l *fflib.FFLexer, tok fflib.FFTok) (interface{}, error) {
+	switch tok {
+	case fflib.FFTok_null:
+		return nil, nil
+	case fflib.FFTok_bool:
+		return l.Output.String() == "true", nil
+	case fflib.FFTok_integer, fflib.FFTok_double:
+		return strconv.ParseFloat(l.Output.String(), 64)
+	case fflib.FFTok_string:
+		b, err := l.CaptureField(tok)
+		if err != nil {
+			return nil, err
+		}
+		var s string
+		err = json.Unmarshal(b, &s)
+		return s, err
+	case fflib.FFTok_left_bracket: // '{'
+		m := map[string]interface{}{}
+		for {
+			tok = l.Scan()
+			if tok == fflib.FFTok_right_bracket && len(m) == 0 {
+				return m, nil
+			}
+			key, err := ffjsonValue(l, tok)
+			if err != nil {
+				return m, err
+			}
+			name, ok := key.(string)
+			if !ok || tok != fflib.FFTok_string {
+				return m, errors.New("ffjson: expected object key")
+			}
+			if l.Scan() != fflib.FFTok_colon {
+				return m, ffjsonErr(l)
+			}
+			v, err := ffjsonValue(l, l.Scan())
+			if err != nil {
+				return m, err
+			}
+			m[name] = v
+			switch l.Scan() {
+			case fflib.FFTok_comma:
+			case fflib.FFTok_right_bracket:
+				return m, nil
+			default:
+				return m, ffjsonErr(l)
+			}
+		}
+	case fflib.FFTok_left_brace: // '['
+		a := []interface{}{}
+		for {
+			tok = l.Scan()
+			if tok == fflib.FFTok_right_brace && len(a) == 0 {
+				return a, nil
+			}
+			v, err := ffjsonValue(l, tok)
+			if err != nil {
+				return a, err
+			}
+			a = append(a, v)
+			switch l.Scan() {
+			case fflib.FFTok_comma:
+			case fflib.FFTok_right_brace:
+				return a, nil
+			default:
+				return a, ffjsonErr(l)
+			}
+		}
+	}
+	return nil, ffjsonErr(l)
+}
+
+func easyjsonGet(data []byte, path string) ([]byte, error) {
+	return easyjsonGetKeys(data, pathKeys(path))
+}
+
+func easyjsonGetKeys(data []byte, keys []string) ([]byte, error) {
+	l := &jlexer.Lexer{Data: data}
+	for _, key := range keys {
+		if !easyjsonChild(l, key) {
+			if err := l.Error(); err != nil {
+				return nil, err
+			}
+			return nil, errNotFound
+		}
+	}
+	raw := l.Raw()
+	return raw, l.Error()
+}
+
+func easyjsonChild(l *jlexer.Lexer, key string) bool {
+	if l.IsDelim('{') {
+		l.Delim('{')
+		for l.Ok() && !l.IsDelim('}') {
+			name := l.UnsafeFieldName(false)
+			l.WantColon()
+			if name == key {
+				return l.Ok()
+			}
+			l.SkipRecursive()
+			l.WantComma()
+		}
+		return false
+	}
+	if l.IsDelim('[') {
+		idx, ok := arrayIndex(key)
+		if !ok {
+			return false
+		}
+		l.Delim('[')
+		for i := 0; l.Ok() && !l.IsDelim(']'); i++ {
+			if i == idx {
+				return l.Ok()
+			}
+			l.SkipRecursive()
+			l.WantComma()
+		}
+	}
+	return false
+}
+
+func easyjsonDecode(data []byte) (interface{}, error) {
+	l := &jlexer.Lexer{Data: data}
+	v := l.Interface()
+	l.Consumed()
+	return v, l.Error()
+}
+
+func jsonparserKeys(path string) []string {
+	keys := pathKeys(path)
+	for i, key := range keys {
+		if _, ok := arrayIndex(key); ok {
+			keys[i] = "[" + key + "]"
+		}
+	}
+	return keys
+}
+
+func jsonparserGet(data []byte, path string) ([]byte, error) {
+	value, typ, _, err := jsonparser.Get(data, jsonparserKeys(path)...)
+	if err != nil {
+		if err == jsonparser.KeyPathNotFoundError {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	if typ == jsonparser.String {
+		// strings are returned without their quotes
+		return []byte(`"` + string(value) + `"`), nil
+	}
+	return value, nil
+}
+
+// jsonparserDecode builds generic values with ObjectEach and ArrayEach.
+// jsonparser has no decoder of its own.
+func jsonparserDecode(data []byte) (interface{}, error) {
+	value, typ, _, err := jsonparser.Get(data)
+	if err != nil {
+		return nil, err
+	}
+	return jsonparserValue(value, typ)
+}
+
+func jsonparserValue(value []byte, typ jsonparser.ValueType) (interface{}, error) {
+	switch typ {
+	case jsonparser.Object:
+		m := map[string]interface{}{}
+		err := jsonparser.ObjectEach(value,
+			func(key, value []byte, typ jsonparser.ValueType, _ int) error {
+				name, err := jsonparser.ParseString(key)
+				if err != nil {
+					return err
+				}
+				m[name], err = jsonparserValue(value, typ)
+				return err
+			})
+		return m, err
+	case jsonparser.Array:
+		a := []interface{}{}
+		var verr error
+		_, err := jsonparser.ArrayEach(value,
+			func(value []byte, typ jsonparser.ValueType, _ int, err error) {
+				if verr == nil {
+					verr = err
+				}
+				if verr == nil {
+					var v interface{}
+					v, verr = jsonparserValue(value, typ)
+					a = append(a, v)
+				}
+			})
+		if err == nil {
+			err = verr
+		}
+		return a, err
+	case jsonparser.String:
+		return jsonparser.ParseString(value)
+	case jsonparser.Number:
+		return jsonparser.ParseFloat(value)
+	case jsonparser.Boolean:
+		return jsonparser.ParseBoolean(value)
+	case jsonparser.Null:
+		return nil, nil
+	}
+	return nil, errors.New("jsonparser: unknown value type")
+}
+
+func jsoniterGet(data []byte, path string) ([]byte, error) {
+	return jsoniterGetKeys(data, pathKeys(path))
+}
+
+func jsoniterGetKeys(data []byte, keys []string) ([]byte, error) {
+	iter := jsoniter.ConfigDefault.BorrowIterator(data)
+	defer jsoniter.ConfigDefault.ReturnIterator(iter)
+	for _, key := range keys {
+		if !jsoniterChild(iter, key) {
+			if iter.Error != nil {
+				return nil, iter.Error
+			}
+			return nil, errNotFound
+		}
+	}
+	raw := iter.SkipAndReturnBytes()
+	if iter.Error != nil {
+		return nil, iter.Error
+	}
+	return raw, nil
+}
+
+func jsoniterChild(iter *jsoniter.Iterator, key string) bool {
+	switch iter.WhatIsNext() {
+	case jsoniter.ObjectValue:
+		for name := iter.ReadObject(); iter.Error == nil; name = iter.ReadObject() {
+			if name == key {
+				return true
+			}
+			if name == "" {
+				// ReadObject returns "" at the end of the object
+				return false
+			}
+			iter.Skip()
+		}
+	case jsoniter.ArrayValue:
+		idx, ok := arrayIndex(key)
+		if !ok {
+			return false
+		}
+		for i := 0; iter.ReadArray(); i++ {
+			if i == idx {
+				return iter.Error == nil
+			}
+			iter.Skip()
+		}
+	}
+	return false
+}
+
+func jsoniterDecode(data []byte) (interface{}, error) {
+	var v interface{}
+	err := jsoniter.Unmarshal(data, &v)
+	return v, err
+}
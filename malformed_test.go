@@ -0,0 +1,164 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// malformedInput is a broken document along with the paths to look up and
+// the values those paths have in the well-formed original.
+type malformedInput struct {
+	name   string
+	json   string
+	paths  []string
+	want   []string // canonical values for paths
+	decode string   // canonical decoded document, empty if unknown
+}
+
+// breakJSON applies one replacement to the example document, and panics if
+// the replacement does not apply so the inputs never silently go stale.
+func breakJSON(old, new string) string {
+	if !strings.Contains(exampleJSON, old) {
+		panic(fmt.Sprintf("breakJSON: %q not found", old))
+	}
+	return strings.Replace(exampleJSON, old, new, 1)
+}
+
+func truncateJSON(frac float64) string {
+	return exampleJSON[:int(float64(len(exampleJSON))*frac)]
+}
+
+var malformedInputs = func() []malformedInput {
+	var want []string
+	for _, path := range benchPaths {
+		want = append(want, canonicalRaw([]byte(gjson.Get(exampleJSON, path).Raw)))
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(exampleJSON), &v); err != nil {
+		panic(err)
+	}
+	decode := canonicalValue(v)
+	inputs := []malformedInput{
+		{name: "well-formed", json: exampleJSON},
+		{name: "missing quote key", json: breakJSON(`"name":`, `name":`)},
+		{name: "missing quote value", json: breakJSON(`"main_window"`, `"main_window`)},
+		{name: "unquoted value", json: breakJSON(`"on"`, `on`)},
+		{name: "trailing comma", json: breakJSON(`"height": 500`, `"height": 500,`)},
+		{name: "double comma", json: breakJSON(`"width": 500,`, `"width": 500,,`)},
+		{name: "leading comma", json: breakJSON(`"window": {`, `"window": {,`)},
+		{name: "missing close", json: strings.TrimSuffix(exampleJSON, "}")},
+		{name: "extra close", json: exampleJSON + "}"},
+		{name: "mismatched close", json: breakJSON("\"center\"\n\t\t},", "\"center\"\n\t\t],")},
+		{name: "truncated 25%", json: truncateJSON(0.25)},
+		{name: "truncated 50%", json: truncateJSON(0.50)},
+		{name: "truncated 75%", json: truncateJSON(0.75)},
+		{name: "truncated -1", json: exampleJSON[:len(exampleJSON)-1]},
+	}
+	for i := range inputs {
+		inputs[i].paths = benchPaths
+		inputs[i].want = want
+		inputs[i].decode = decode
+	}
+	// basicJSON is broken by hand and has no well-formed original, so any
+	// successful decode of it is wrong.
+	return append(inputs, malformedInput{
+		name:  "basic",
+		json:  basicJSON,
+		paths: []string{"age", "loggy.programmers.1.lastName", "lastly.yay"},
+		want:  []string{`100`, `"Hunter"`, `"final"`},
+	})
+}()
+
+// canonicalRaw normalizes raw JSON so that values from different
+// libraries can be compared. Invalid JSON is returned as is.
+func canonicalRaw(raw []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	return canonicalValue(v)
+}
+
+func canonicalValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// classify reports what a library did with an input. "ok" means the value
+// from the well-formed original came back without an error, "partial" means
+// an error came back with some data, or a truncated value without one, and
+// "wrong" means a different value came back without an error.
+func classify(got string, err error, want string) string {
+	empty := got == "" || got == "null" || got == "{}" || got == "[]"
+	switch {
+	case err == errNotFound:
+		return "not found"
+	case err != nil && empty:
+		return "error"
+	case err != nil:
+		return "partial"
+	case want != "" && got == want:
+		return "ok"
+	case want != "" && len(got) < len(want) && strings.HasPrefix(want, got):
+		return "partial"
+	}
+	return "wrong"
+}
+
+// catch turns a panic into an outcome.
+func catch(outcome *string) {
+	if r := recover(); r != nil {
+		*outcome = "panic"
+	}
+}
+
+func malformedGet(lib library, data []byte, path, want string) (outcome string) {
+	defer catch(&outcome)
+	raw, err := lib.get(data, path)
+	return classify(canonicalRaw(raw), err, want)
+}
+
+func malformedDecode(lib library, data []byte, want string) (outcome string) {
+	defer catch(&outcome)
+	v, err := lib.decode(data)
+	return classify(canonicalValue(v), err, want)
+}
+
+func TestMalformedMatrix(t *testing.T) {
+	header := []string{"input", "operation"}
+	for _, lib := range libraries {
+		header = append(header, lib.name)
+	}
+	tbl := newTable("Behaviour on malformed input", header...)
+	for _, in := range malformedInputs {
+		data := []byte(in.json)
+		for i, path := range in.paths {
+			row := []string{in.name, "get " + path}
+			for _, lib := range libraries {
+				outcome := malformedGet(lib, data, path, in.want[i])
+				if in.name == "well-formed" && outcome != "ok" {
+					t.Errorf("%s: get %s: %s", lib.name, path, outcome)
+				}
+				row = append(row, outcome)
+			}
+			tbl.add(row...)
+		}
+		row := []string{in.name, "decode"}
+		for _, lib := range libraries {
+			outcome := malformedDecode(lib, data, in.decode)
+			if in.name == "well-formed" && outcome != "ok" {
+				t.Errorf("%s: decode: %s", lib.name, outcome)
+			}
+			row = append(row, outcome)
+		}
+		tbl.add(row...)
+	}
+	publish(t, tbl)
+}
@@ -0,0 +1,212 @@
+package gjson_benchmarks
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	mmapSize = flag.Int("mmap", 0,
+		"generate a document of this many MB on disk and benchmark lookups in it through mmap")
+	mmapDir = flag.String("mmap.dir", os.TempDir(),
+		"directory for the -mmap document, which is kept for later runs")
+)
+
+var mmapPayload = strings.Repeat("x", 200)
+
+// mmapRecordSize is roughly the size of one record, for turning the
+// requested size into a record count.
+var mmapRecordSize = len(fmt.Sprintf(`{"id":%d,"name":"record %d","payload":"%s"},`,
+	1<<24, 1<<24, mmapPayload))
+
+// writeMmapDoc writes {"records":[...]} with n records, where record i
+// has the id i.
+func writeMmapDoc(path string, n int) error {
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriterSize(f, 1<<20)
+	w.WriteString(`{"records":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		fmt.Fprintf(w, `{"id":%d,"name":"record %d","payload":"%s"}`, i, i, mmapPayload)
+	}
+	w.WriteString("]}\n")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// mmapDoc maps a document of n records from dir, writing it first if it
+// is not there yet.
+func mmapDoc(dir string, n int) ([]byte, error) {
+	path := filepath.Join(dir, fmt.Sprintf("gjson-benchmarks-mmap-%d.json", n))
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := writeMmapDoc(path, n); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// procStat is the memory accounting of /proc/self/stat.
+type procStat struct {
+	minflt, majflt uint64
+	rss            uint64 // bytes
+}
+
+func readProcStat() (procStat, error) {
+	data, err := ioutil.ReadFile("/proc/self/stat")
+	if err != nil {
+		return procStat{}, err
+	}
+	// The command name may contain spaces, so count from its ')'.
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return procStat{}, fmt.Errorf("malformed /proc/self/stat")
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 22 {
+		return procStat{}, fmt.Errorf("malformed /proc/self/stat")
+	}
+	// fields[0] is field 3 of proc(5): state
+	field := func(n int) uint64 {
+		v, _ := strconv.ParseUint(fields[n-3], 10, 64)
+		return v
+	}
+	return procStat{
+		minflt: field(10),
+		majflt: field(12),
+		rss:    field(24) * uint64(os.Getpagesize()),
+	}, nil
+}
+
+// mmapPositions are the records that are looked up, by record count.
+var mmapPositions = []struct {
+	name string
+	pos  func(n int) int
+}{
+	{"first", func(n int) int { return 0 }},
+	{"middle", func(n int) int { return n / 2 }},
+	{"last", func(n int) int { return n - 1 }},
+}
+
+func mmapPath(i int) string { return "records." + strconv.Itoa(i) + ".id" }
+
+// mmapGetters return the raw id of record i.
+var mmapGetters = []struct {
+	name string
+	get  func(data []byte, i int) ([]byte, error)
+}{
+	{"gjson", func(data []byte, i int) ([]byte, error) {
+		return []byte(gjson.GetBytes(data, mmapPath(i)).Raw), nil
+	}},
+	{"jsonparser", func(data []byte, i int) ([]byte, error) {
+		value, _, _, err := jsonparser.Get(data, "records", "["+strconv.Itoa(i)+"]", "id")
+		return value, err
+	}},
+	{"json/Decoder", func(data []byte, i int) ([]byte, error) {
+		return decoderGet(bytes.NewReader(data), mmapPath(i))
+	}},
+	{"jsoniter/Parse", func(data []byte, i int) ([]byte, error) {
+		return jsoniterStreamGet(bytes.NewReader(data), mmapPath(i))
+	}},
+}
+
+func TestMmapLookup(t *testing.T) {
+	const n = 4000
+	data, err := mmapDoc(t.TempDir(), n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Munmap(data)
+	for _, p := range mmapPositions {
+		i := p.pos(n)
+		for _, g := range mmapGetters {
+			raw, err := g.get(data, i)
+			if err != nil || string(raw) != strconv.Itoa(i) {
+				t.Fatalf("%s: %s: got %q, %v", p.name, g.name, raw, err)
+			}
+		}
+	}
+	if _, err := readProcStat(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// BenchmarkMmap looks up records in a document of -mmap MB. Before each
+// benchmark the mapping is dropped from memory, so the page faults and
+// RSS growth show how much of the file each library touches.
+func BenchmarkMmap(b *testing.B) {
+	if *mmapSize <= 0 {
+		b.Skip("enable with -mmap=MB")
+	}
+	n := (*mmapSize << 20) / mmapRecordSize
+	data, err := mmapDoc(*mmapDir, n)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer syscall.Munmap(data)
+	for _, p := range mmapPositions {
+		i := p.pos(n)
+		b.Run(p.name, func(b *testing.B) {
+			for _, g := range mmapGetters {
+				b.Run(g.name, func(b *testing.B) {
+					if err := syscall.Madvise(data, syscall.MADV_DONTNEED); err != nil {
+						b.Fatal(err)
+					}
+					before, err := readProcStat()
+					if err != nil {
+						b.Fatal(err)
+					}
+					b.ReportAllocs()
+					b.ResetTimer()
+					stopMetrics := reportMetrics(b)
+					for j := 0; j < b.N; j++ {
+						raw, err := g.get(data, i)
+						if err != nil || string(raw) != strconv.Itoa(i) {
+							b.Fatalf("got %q, %v", raw, err)
+						}
+					}
+					stopMetrics()
+					after, err := readProcStat()
+					if err != nil {
+						b.Fatal(err)
+					}
+					b.ReportMetric(float64(after.minflt-before.minflt)/float64(b.N), "minflt/op")
+					b.ReportMetric(float64(after.majflt-before.majflt)/float64(b.N), "majflt/op")
+					b.ReportMetric((float64(after.rss)-float64(before.rss))/(1<<20), "rss-growth-MB")
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,458 @@
+package gjson_benchmarks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// jsonObject is an object that keeps the order of its members, so that the
+// encoding/json round trips can reproduce the order sensitive modifiers.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o jsonObject) get(key string) (interface{}, bool) {
+	for _, m := range o {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+	return nil, false
+}
+
+// decodeOrdered decodes with encoding/json into jsonObject, []interface{}
+// and json.Number values.
+func decodeOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after value")
+	}
+	return v, nil
+}
+
+func decodeOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		o := jsonObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, jsonMember{key.(string), value})
+		}
+		_, err = dec.Token()
+		return o, err
+	case json.Delim('['):
+		a := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, value)
+		}
+		_, err = dec.Token()
+		return a, err
+	}
+	return tok, nil
+}
+
+// navigate follows a simple gjson path, where "#" maps the rest of the
+// path over every element of an array.
+func navigate(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+	key, rest := path, ""
+	if i := strings.IndexByte(path, '.'); i >= 0 {
+		key, rest = path[:i], path[i+1:]
+	}
+	switch vv := v.(type) {
+	case jsonObject:
+		child, _ := vv.get(key)
+		return navigate(child, rest)
+	case []interface{}:
+		if key == "#" {
+			a := []interface{}{}
+			for _, elem := range vv {
+				if child := navigate(elem, rest); child != nil {
+					a = append(a, child)
+				}
+			}
+			return a
+		}
+		if i, ok := arrayIndex(key); ok && i < len(vv) {
+			return navigate(vv[i], rest)
+		}
+	}
+	return nil
+}
+
+// modifierCase is a gjson modifier and the same transform applied to
+// values decoded with encoding/json.
+type modifierCase struct {
+	name      string
+	modifier  string
+	target    string // kind of value the modifier is applied to
+	stringify bool   // output is a string holding JSON
+	transform func(v interface{}) interface{}
+
+	// format reformats the raw target instead of transforming its value,
+	// and the output is compared byte for byte. @pretty packs arrays that
+	// fit in 80 columns onto one line, which json.Indent never does, so
+	// packed outputs are compared after json.Compact.
+	format func(dst *bytes.Buffer, src []byte) error
+	packed bool
+}
+
+func identity(v interface{}) interface{} { return v }
+
+var modifierCases = []modifierCase{
+	{name: "this", modifier: "@this", target: "root", transform: identity},
+	{name: "valid", modifier: "@valid", target: "root", transform: identity},
+	{name: "ugly", modifier: "@ugly", target: "root", format: json.Compact},
+	{name: "pretty", modifier: "@pretty", target: "root", packed: true,
+		format: func(dst *bytes.Buffer, src []byte) error {
+			return json.Indent(dst, src, "", "  ")
+		}},
+	{name: "reverse/object", modifier: "@reverse", target: "object",
+		transform: func(v interface{}) interface{} {
+			o := v.(jsonObject)
+			r := make(jsonObject, len(o))
+			for i := range o {
+				r[len(o)-1-i] = o[i]
+			}
+			return r
+		}},
+	{name: "reverse/array", modifier: "@reverse", target: "array",
+		transform: func(v interface{}) interface{} {
+			a := v.([]interface{})
+			r := make([]interface{}, len(a))
+			for i := range a {
+				r[len(a)-1-i] = a[i]
+			}
+			return r
+		}},
+	{name: "keys", modifier: "@keys", target: "object",
+		transform: func(v interface{}) interface{} {
+			keys := []interface{}{}
+			for _, m := range v.(jsonObject) {
+				keys = append(keys, m.key)
+			}
+			return keys
+		}},
+	{name: "values", modifier: "@values", target: "object",
+		transform: func(v interface{}) interface{} {
+			values := []interface{}{}
+			for _, m := range v.(jsonObject) {
+				values = append(values, m.value)
+			}
+			return values
+		}},
+	{name: "flatten", modifier: "@flatten", target: "arrays",
+		transform: func(v interface{}) interface{} {
+			flat := []interface{}{}
+			for _, elem := range v.([]interface{}) {
+				if a, ok := elem.([]interface{}); ok {
+					flat = append(flat, a...)
+				} else {
+					flat = append(flat, elem)
+				}
+			}
+			return flat
+		}},
+	{name: "join", modifier: "@join", target: "objects",
+		transform: func(v interface{}) interface{} {
+			// first position of a key, last value
+			joined := jsonObject{}
+			index := map[string]int{}
+			for _, elem := range v.([]interface{}) {
+				o, _ := elem.(jsonObject)
+				for _, m := range o {
+					if i, ok := index[m.key]; ok {
+						joined[i].value = m.value
+					} else {
+						index[m.key] = len(joined)
+						joined = append(joined, m)
+					}
+				}
+			}
+			return joined
+		}},
+	{name: "group", modifier: "@group", target: "columns",
+		transform: func(v interface{}) interface{} {
+			rows := []jsonObject{}
+			for _, m := range v.(jsonObject) {
+				column, ok := m.value.([]interface{})
+				if !ok {
+					continue
+				}
+				for i, elem := range column {
+					if i == len(rows) {
+						rows = append(rows, jsonObject{})
+					}
+					rows[i] = append(rows[i], jsonMember{m.key, elem})
+				}
+			}
+			return rows
+		}},
+	{name: "dig", modifier: "@dig:name", target: "root",
+		transform: func(v interface{}) interface{} {
+			return dig([]interface{}{}, v, "name")
+		}},
+	{name: "tostr", modifier: "@tostr", target: "object", stringify: true,
+		transform: func(v interface{}) interface{} {
+			b, _ := json.Marshal(v)
+			return string(b)
+		}},
+	{name: "tostr/fromstr", modifier: "@tostr|@fromstr", target: "object",
+		transform: func(v interface{}) interface{} {
+			b, _ := json.Marshal(v)
+			v, _ = decodeOrdered(b)
+			return v
+		}},
+}
+
+// dig appends every value of key at any depth, in document order, the way
+// @dig does.
+func dig(found []interface{}, v interface{}, key string) []interface{} {
+	switch vv := v.(type) {
+	case jsonObject:
+		if value, ok := vv.get(key); ok {
+			found = append(found, value)
+		}
+		for _, m := range vv {
+			found = dig(found, m.value, key)
+		}
+	case []interface{}:
+		for _, elem := range vv {
+			found = dig(found, elem, key)
+		}
+	}
+	return found
+}
+
+// modifierTargets are the paths, per corpus, of the values that each kind
+// of modifier is applied to. The example document has no arrays.
+var modifierTargets = map[string]map[string]string{
+	"example": {
+		"root":   "",
+		"object": "widget.window",
+	},
+	"basic": {
+		"root": "",
+	},
+	"twitterMedium": {
+		"root":    "",
+		"object":  "search_metadata",
+		"array":   "statuses",
+		"arrays":  "statuses.#.entities.hashtags",
+		"objects": "statuses.#.user",
+		"columns": "statuses.0.entities",
+	},
+	"twitterLarge": {
+		"root":    "",
+		"object":  "search_metadata",
+		"array":   "statuses",
+		"arrays":  "statuses.#.entities.hashtags",
+		"objects": "statuses.#.user",
+		"columns": "statuses.0.entities",
+	},
+}
+
+// modifierPath returns the gjson path that applies the modifier to the
+// corpus, or false when it does not apply. Only @valid is meaningful on the
+// malformed basic corpus.
+func modifierPath(c corpus, mc modifierCase) (string, bool) {
+	if c.name == "basic" && mc.name != "valid" {
+		return "", false
+	}
+	target, ok := modifierTargets[c.name][mc.target]
+	if !ok {
+		return "", false
+	}
+	if target == "" {
+		return mc.modifier, true
+	}
+	return target + "|" + mc.modifier, true
+}
+
+func gjsonModifier(data []byte, path string) string {
+	return gjson.GetBytes(data, path).Raw
+}
+
+func stdjsonModifier(data []byte, target string, mc modifierCase) string {
+	if mc.format != nil {
+		// formatting modifiers are only applied to the root
+		var buf bytes.Buffer
+		if err := mc.format(&buf, data); err != nil {
+			return ""
+		}
+		return buf.String()
+	}
+	v, err := decodeOrdered(data)
+	if err != nil {
+		return ""
+	}
+	out, err := json.Marshal(mc.transform(navigate(v, target)))
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// canonicalOrdered normalizes whitespace, escapes and number formatting
+// while keeping the member order.
+func canonicalOrdered(s string, stringify bool) string {
+	if s == "" {
+		return ""
+	}
+	if stringify {
+		var inner string
+		if err := json.Unmarshal([]byte(s), &inner); err != nil {
+			return s
+		}
+		s = inner
+	}
+	v, err := decodeOrdered([]byte(s))
+	if err != nil {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return s
+	}
+	return string(b)
+}
+
+// compacted removes the whitespace and leaves the escapes and numbers as
+// they are.
+func compacted(s string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(s)); err != nil {
+		return s
+	}
+	return buf.String()
+}
+
+func checkModifier(c corpus, mc modifierCase, got, want string) error {
+	if mc.format != nil {
+		if mc.packed {
+			got, want = compacted(got), compacted(want)
+		}
+		if got != want {
+			return fmt.Errorf("%s on %s: gjson and encoding/json output differ", mc.name, c.name)
+		}
+		return nil
+	}
+	if canonicalOrdered(got, mc.stringify) != canonicalOrdered(want, mc.stringify) {
+		return fmt.Errorf("%s on %s: gjson and encoding/json disagree", mc.name, c.name)
+	}
+	return nil
+}
+
+func TestModifierEquality(t *testing.T) {
+	for _, c := range corpora {
+		data := []byte(c.json)
+		for _, mc := range modifierCases {
+			path, ok := modifierPath(c, mc)
+			if !ok {
+				continue
+			}
+			target := modifierTargets[c.name][mc.target]
+			got := gjsonModifier(data, path)
+			want := stdjsonModifier(data, target, mc)
+			if err := checkModifier(c, mc, got, want); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func BenchmarkModifier(b *testing.B) {
+	for _, mc := range modifierCases {
+		b.Run(mc.name, func(b *testing.B) {
+			for _, c := range corpora {
+				path, ok := modifierPath(c, mc)
+				if !ok {
+					continue
+				}
+				target := modifierTargets[c.name][mc.target]
+				data := []byte(c.json)
+				want := stdjsonModifier(data, target, mc)
+				b.Run(c.name, func(b *testing.B) {
+					b.Run("gjson", func(b *testing.B) {
+						var out string
+						b.ReportAllocs()
+						stopMetrics := reportMetrics(b)
+						for i := 0; i < b.N; i++ {
+							out = gjsonModifier(data, path)
+						}
+						stopMetrics()
+						if err := checkModifier(c, mc, out, want); err != nil {
+							b.Fatal(err)
+						}
+					})
+					b.Run("encoding/json", func(b *testing.B) {
+						var out string
+						b.ReportAllocs()
+						stopMetrics := reportMetrics(b)
+						for i := 0; i < b.N; i++ {
+							out = stdjsonModifier(data, target, mc)
+						}
+						stopMetrics()
+						if out != want {
+							b.Fatal("output changed between runs")
+						}
+					})
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package gjson_benchmarks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+// ndjsonCopies is how many times the statuses of twitter.json are
+// repeated in the NDJSON corpus.
+const ndjsonCopies = 10
+
+const ndjsonField = "id_str"
+
+var (
+	ndjsonOnce sync.Once
+	ndjsonData string
+	ndjsonIDs  []string // raw id_str of every record
+)
+
+// ndjson returns the statuses of twitter.json, compacted to one record
+// per line.
+func ndjson() (string, []string) {
+	ndjsonOnce.Do(func() {
+		var sb strings.Builder
+		var buf bytes.Buffer
+		for i := 0; i < ndjsonCopies; i++ {
+			for _, status := range gjson.Get(twitterLarge, "statuses").Array() {
+				buf.Reset()
+				if err := json.Compact(&buf, []byte(status.Raw)); err != nil {
+					panic(err)
+				}
+				sb.Write(buf.Bytes())
+				sb.WriteByte('\n')
+				ndjsonIDs = append(ndjsonIDs, status.Get(ndjsonField).Raw)
+			}
+		}
+		ndjsonData = sb.String()
+	})
+	return ndjsonData, ndjsonIDs
+}
+
+type ndjsonReader struct {
+	name string
+	read func(data string, ids []string) ([]string, error)
+}
+
+// ndjsonReaders collect the raw id_str of every record.
+var ndjsonReaders = func() []ndjsonReader {
+	readers := []ndjsonReader{
+		{"gjson/ForEachLine", func(data string, ids []string) ([]string, error) {
+			gjson.ForEachLine(data, func(line gjson.Result) bool {
+				ids = append(ids, line.Get(ndjsonField).Raw)
+				return true
+			})
+			return ids, nil
+		}},
+		{"gjson/..", func(data string, ids []string) ([]string, error) {
+			for _, id := range gjson.Get(data, "..#."+ndjsonField).Array() {
+				ids = append(ids, id.Raw)
+			}
+			return ids, nil
+		}},
+		{"json/Decoder", func(data string, ids []string) ([]string, error) {
+			dec := json.NewDecoder(strings.NewReader(data))
+			for dec.More() {
+				var rec struct {
+					ID json.RawMessage `json:"id_str"`
+				}
+				if err := dec.Decode(&rec); err != nil {
+					return ids, err
+				}
+				ids = append(ids, string(rec.ID))
+			}
+			return ids, nil
+		}},
+		{"jsoniter/stream", func(data string, ids []string) ([]string, error) {
+			iter := jsoniter.Parse(jsoniter.ConfigDefault, strings.NewReader(data), 4096)
+			for iter.WhatIsNext() == jsoniter.ObjectValue {
+				var id string
+				for field := iter.ReadObject(); field != ""; field = iter.ReadObject() {
+					if field == ndjsonField {
+						id = string(iter.SkipAndReturnBytes())
+					} else {
+						iter.Skip()
+					}
+				}
+				if iter.Error != nil {
+					return ids, iter.Error
+				}
+				ids = append(ids, id)
+			}
+			if iter.Error != nil && iter.Error != io.EOF {
+				return ids, iter.Error
+			}
+			return ids, nil
+		}},
+	}
+	for _, lib := range libraries {
+		lib := lib
+		readers = append(readers, ndjsonReader{"bufio/" + lib.name, func(data string, ids []string) ([]string, error) {
+			sc := bufio.NewScanner(strings.NewReader(data))
+			sc.Buffer(nil, 1<<20)
+			for sc.Scan() {
+				raw, err := lib.get(sc.Bytes(), ndjsonField)
+				if err != nil {
+					return ids, err
+				}
+				ids = append(ids, string(raw))
+			}
+			return ids, sc.Err()
+		}})
+	}
+	return readers
+}()
+
+func checkNDJSON(got, want []string) error {
+	if len(got) != len(want) {
+		return fmt.Errorf("got %d records, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return fmt.Errorf("record %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+	return nil
+}
+
+func TestNDJSON(t *testing.T) {
+	data, want := ndjson()
+	statuses := int(gjson.Get(twitterLarge, "statuses.#").Int())
+	if n := strings.Count(data, "\n"); n != len(want) || n != ndjsonCopies*statuses {
+		t.Fatalf("corpus has %d lines for %d ids", n, len(want))
+	}
+	for _, r := range ndjsonReaders {
+		ids, err := r.read(data, nil)
+		if err == nil {
+			err = checkNDJSON(ids, want)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", r.name, err)
+		}
+	}
+}
+
+func BenchmarkNDJSON(b *testing.B) {
+	data, want := ndjson()
+	for _, r := range ndjsonReaders {
+		b.Run(r.name, func(b *testing.B) {
+			var ids []string
+			var err error
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			stopMetrics := reportMetrics(b)
+			for i := 0; i < b.N; i++ {
+				if ids, err = r.read(data, ids[:0]); err != nil {
+					b.Fatal(err)
+				}
+			}
+			stopMetrics()
+			if err := checkNDJSON(ids, want); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
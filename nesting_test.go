@@ -0,0 +1,184 @@
+package gjson_benchmarks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var nestingEnabled = flag.Bool("nesting", false,
+	"run every library on deeply nested documents in subprocesses and report the outcomes")
+
+var nestingDepths = []int{10, 100, 10000, 1000000}
+
+// nestingMaxStack bounds the goroutine stack in the child processes, so
+// that an overflow fails in well under a second instead of growing to the
+// 1GB default.
+const nestingMaxStack = 64 << 20
+
+// nestingTimeout stops runs that are quadratic in the depth.
+const nestingTimeout = 10 * time.Second
+
+const nestingEnv = "GJSON_BENCHMARKS_NESTING"
+
+// nestedJSON returns a document nested depth levels deep, and the path to
+// the innermost value.
+func nestedJSON(kind string, depth int) (string, string) {
+	if kind == "array" {
+		return strings.Repeat("[", depth) + "1" + strings.Repeat("]", depth),
+			strings.TrimSuffix(strings.Repeat("0.", depth), ".")
+	}
+	return strings.Repeat(`{"a":`, depth) + "1" + strings.Repeat("}", depth),
+		strings.TrimSuffix(strings.Repeat("a.", depth), ".")
+}
+
+type nestingResult struct {
+	Outcome string
+	Nanos   int64
+	Alloc   uint64
+}
+
+// TestNestingHelper runs a single library operation on a nested document
+// when started by TestNesting, and prints the result on stdout.
+func TestNestingHelper(t *testing.T) {
+	spec := os.Getenv(nestingEnv)
+	if spec == "" {
+		t.Skip("only runs as a subprocess of TestNesting")
+	}
+	var libName, op, kind string
+	var depth int
+	parts := strings.Split(spec, ",")
+	if len(parts) != 4 {
+		t.Fatalf("bad spec %q", spec)
+	}
+	libName, op, kind = parts[0], parts[1], parts[2]
+	depth, _ = strconv.Atoi(parts[3])
+	var lib library
+	for _, l := range libraries {
+		if l.name == libName {
+			lib = l
+		}
+	}
+	doc, path := nestedJSON(kind, depth)
+	data := []byte(doc)
+	debug.SetMaxStack(nestingMaxStack)
+
+	var res nestingResult
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	if op == "get" {
+		res.Outcome = malformedGet(lib, data, path, "1")
+	} else {
+		var v interface{}
+		func() {
+			defer catch(&res.Outcome)
+			var err error
+			v, err = lib.decode(data)
+			switch {
+			case err != nil:
+				res.Outcome = "error"
+			case nestedValue(v, depth) != "1":
+				res.Outcome = "wrong"
+			default:
+				res.Outcome = "ok"
+			}
+		}()
+	}
+	res.Nanos = int64(time.Since(start))
+	runtime.ReadMemStats(&after)
+	res.Alloc = after.TotalAlloc - before.TotalAlloc
+	b, _ := json.Marshal(res)
+	fmt.Printf("NESTING %s\n", b)
+}
+
+// nestedValue walks down to the innermost decoded value without recursion.
+func nestedValue(v interface{}, depth int) string {
+	for i := 0; i < depth; i++ {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			v = vv["a"]
+		case []interface{}:
+			if len(vv) == 0 {
+				return ""
+			}
+			v = vv[0]
+		default:
+			return ""
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// runNesting runs one operation in a subprocess and reports a fatal stack
+// overflow, or any other crash, as the outcome.
+func runNesting(lib, op, kind string, depth int) nestingResult {
+	ctx, cancel := context.WithTimeout(context.Background(), nestingTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=^TestNestingHelper$")
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s,%s,%s,%d", nestingEnv, lib, op, kind, depth))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if strings.HasPrefix(line, "NESTING ") {
+			var res nestingResult
+			if json.Unmarshal([]byte(line[len("NESTING "):]), &res) == nil {
+				return res
+			}
+		}
+	}
+	out := stdout.String() + stderr.String()
+	switch {
+	case ctx.Err() != nil:
+		return nestingResult{Outcome: "timeout"}
+	case strings.Contains(out, "stack overflow"),
+		strings.Contains(out, "goroutine stack exceeds"):
+		return nestingResult{Outcome: "stack overflow"}
+	case strings.Contains(out, "panic:"):
+		return nestingResult{Outcome: "panic"}
+	}
+	return nestingResult{Outcome: fmt.Sprintf("crash (%v)", err)}
+}
+
+func TestNesting(t *testing.T) {
+	if !*nestingEnabled {
+		t.Skip("enable with -nesting")
+	}
+	if os.Getenv(nestingEnv) != "" {
+		return
+	}
+	tbl := newTable(fmt.Sprintf("Deep nesting (max stack %dMB, timeout %s)",
+		nestingMaxStack>>20, nestingTimeout),
+		"kind", "depth", "library", "operation", "outcome", "time", "alloc")
+	for _, kind := range []string{"array", "object"} {
+		for _, depth := range nestingDepths {
+			for _, lib := range libraries {
+				for _, op := range []string{"get", "decode"} {
+					res := runNesting(lib.name, op, kind, depth)
+					var elapsed, alloc string
+					if res.Nanos > 0 {
+						elapsed = roundDuration(time.Duration(res.Nanos))
+						alloc = fmt.Sprintf("%dKB", res.Alloc>>10)
+					}
+					tbl.add(kind, strconv.Itoa(depth), lib.name, op,
+						res.Outcome, elapsed, alloc)
+				}
+			}
+		}
+	}
+	publish(t, tbl)
+}
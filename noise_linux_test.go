@@ -0,0 +1,66 @@
+package gjson_benchmarks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// pinCPUs sets the affinity of every thread of the process. Threads the
+// runtime starts later inherit it.
+func pinCPUs(cpus []int) error {
+	var mask [16]uint64
+	for _, cpu := range cpus {
+		if cpu >= len(mask)*64 {
+			return fmt.Errorf("cpu %d out of range", cpu)
+		}
+		mask[cpu/64] |= 1 << uint(cpu%64)
+	}
+	tasks, err := ioutil.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY,
+			uintptr(tid), unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+		if errno != 0 {
+			return fmt.Errorf("sched_setaffinity: %v", errno)
+		}
+	}
+	return nil
+}
+
+// cpuGovernors reads the cpufreq governor of the given CPUs, or of every
+// CPU if none are given. CPUs without cpufreq, as in most VMs, are left
+// out.
+func cpuGovernors(cpus []int) map[int]string {
+	var paths []string
+	if len(cpus) == 0 {
+		paths, _ = filepath.Glob("/sys/devices/system/cpu/cpu[0-9]*/cpufreq/scaling_governor")
+	}
+	for _, cpu := range cpus {
+		paths = append(paths, fmt.Sprintf("/sys/devices/system/cpu/cpu%d/cpufreq/scaling_governor", cpu))
+	}
+	governors := map[int]string{}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		dir := filepath.Base(filepath.Dir(filepath.Dir(path)))
+		cpu, err := strconv.Atoi(strings.TrimPrefix(dir, "cpu"))
+		if err != nil {
+			continue
+		}
+		governors[cpu] = strings.TrimSpace(string(data))
+	}
+	return governors
+}
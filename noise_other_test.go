@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package gjson_benchmarks
+
+import "errors"
+
+func pinCPUs(cpus []int) error {
+	return errors.New("pinning to CPUs is only supported on Linux")
+}
+
+func cpuGovernors(cpus []int) map[int]string {
+	return nil
+}
@@ -0,0 +1,229 @@
+package gjson_benchmarks
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	pinList = flag.String("cpus", "",
+		"pin the process to this CPU list, such as 0-3 or 2,4 (Linux only)")
+	warmupIters = flag.Int("warmup", 10,
+		"iterations to run before each report measurement, for at most half its duration")
+	repetitions = flag.Int("reps", 5,
+		"repetitions of each report measurement, for the coefficient of variation")
+	noiseLimit = flag.Float64("noise", 0.05,
+		"flag results whose coefficient of variation is above this as unreliable")
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if err := setupNoiseControl(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	os.Exit(m.Run())
+}
+
+// setupNoiseControl pins the process if asked to and warns about CPUs
+// that may change frequency during a run.
+func setupNoiseControl() error {
+	var cpus []int
+	if *pinList != "" {
+		var err error
+		if cpus, err = parseCPUList(*pinList); err != nil {
+			return fmt.Errorf("-cpus: %v", err)
+		}
+		if err := pinCPUs(cpus); err != nil {
+			return fmt.Errorf("-cpus: %v", err)
+		}
+		if os.Getenv("GOMAXPROCS") == "" {
+			runtime.GOMAXPROCS(len(cpus))
+		}
+	}
+	governors := cpuGovernors(cpus)
+	var slow []string
+	for cpu, gov := range governors {
+		if gov != "performance" {
+			slow = append(slow, fmt.Sprintf("cpu%d=%s", cpu, gov))
+		}
+	}
+	if len(slow) > 0 {
+		sort.Strings(slow)
+		fmt.Fprintf(os.Stderr, "warning: frequency governor is not \"performance\" (%s); timings may be noisy\n",
+			strings.Join(slow, " "))
+	}
+	return nil
+}
+
+// parseCPUList parses the kernel's CPU list format, as in "0-3,8".
+func parseCPUList(s string) ([]int, error) {
+	var cpus []int
+	seen := map[int]bool{}
+	for _, part := range strings.Split(s, ",") {
+		lo, hi := part, part
+		if i := strings.IndexByte(part, '-'); i >= 0 {
+			lo, hi = part[:i], part[i+1:]
+		}
+		first, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		last, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil || first < 0 || last < first {
+			return nil, fmt.Errorf("bad CPU range %q", part)
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			if !seen[cpu] {
+				seen[cpu] = true
+				cpus = append(cpus, cpu)
+			}
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+// meanCV returns the mean and the coefficient of variation of xs.
+func meanCV(xs []float64) (float64, float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+	if len(xs) < 2 || mean == 0 {
+		return mean, 0
+	}
+	var sq float64
+	for _, x := range xs {
+		sq += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(sq/float64(len(xs)-1)) / mean
+}
+
+// sample is a repeated measurement of the time per call.
+type sample struct {
+	mean time.Duration
+	cv   float64
+}
+
+func (s sample) per(n int) sample {
+	return sample{s.mean / time.Duration(n), s.cv}
+}
+
+// warmup runs f -warmup times, or fewer once that takes half of d, so
+// that slow operations are not warmed up for longer than they are measured.
+func warmup(d time.Duration, f func()) {
+	start := time.Now()
+	for i := 0; i < *warmupIters && time.Since(start) < d/2; i++ {
+		f()
+	}
+}
+
+// repCount is -reps, at least 1.
+func repCount() int {
+	if *repetitions < 1 {
+		return 1
+	}
+	return *repetitions
+}
+
+// repeat runs measure -reps times and returns the mean and coefficient
+// of variation of its results.
+func repeat(measure func() float64) (float64, float64) {
+	xs := make([]float64, repCount())
+	for i := range xs {
+		xs[i] = measure()
+	}
+	return meanCV(xs)
+}
+
+// timePerOp warms f up, then times it for about d in total, split over
+// the repetitions. It is coarser than testing.Benchmark, which would make
+// the reports take minutes.
+func timePerOp(d time.Duration, f func()) sample {
+	warmup(d, f)
+	rep := d / time.Duration(repCount())
+	mean, cv := repeat(func() float64 {
+		var n int
+		start := time.Now()
+		for time.Since(start) < rep || n == 0 {
+			f()
+			n++
+		}
+		return float64(time.Since(start)) / float64(n)
+	})
+	return sample{time.Duration(mean), cv}
+}
+
+// benchRuns holds the ns/op of every repetition of each benchmark, as
+// asked for with -count.
+var benchRuns = struct {
+	sync.Mutex
+	m map[string][]float64
+}{m: map[string][]float64{}}
+
+// noteRun records the ns/op of a benchmark run and reports the variation
+// across repetitions. The testing package starts every repetition with
+// b.N == 1 and keeps the last run, so a run that started with b.N == 1
+// starts a new repetition and later ones replace it. Some benchmarks
+// scale b.N when they finish, hence startN.
+func noteRun(b *testing.B, startN int, nsPerOp float64) {
+	benchRuns.Lock()
+	runs := benchRuns.m[b.Name()]
+	if startN == 1 || len(runs) == 0 {
+		runs = append(runs, nsPerOp)
+	} else {
+		runs[len(runs)-1] = nsPerOp
+	}
+	benchRuns.m[b.Name()] = runs
+	_, cv := meanCV(runs)
+	benchRuns.Unlock()
+	if len(runs) < 2 {
+		return
+	}
+	b.ReportMetric(cv*100, "cv-%")
+	if cv > *noiseLimit {
+		b.ReportMetric(1, "unreliable")
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+	}{
+		{"0", "[0]"},
+		{"0-3", "[0 1 2 3]"},
+		{"4,0-1,1", "[0 1 4]"},
+		{"3-1", "error"},
+		{"a", "error"},
+		{"", "error"},
+	} {
+		got := "error"
+		if cpus, err := parseCPUList(tc.in); err == nil {
+			got = fmt.Sprint(cpus)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: got %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestMeanCV(t *testing.T) {
+	if mean, cv := meanCV([]float64{10, 10, 10}); mean != 10 || cv != 0 {
+		t.Fatalf("got %v %v", mean, cv)
+	}
+	if _, cv := meanCV([]float64{9, 11}); math.Abs(cv-math.Sqrt2/10) > 1e-9 {
+		t.Fatalf("got cv %v", cv)
+	}
+}
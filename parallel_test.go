@@ -0,0 +1,163 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mailru/easyjson/jlexer"
+)
+
+// parallelProcs is the GOMAXPROCS sweep: doubling from 1 up to the number
+// of CPUs, which always ends the sweep.
+func parallelProcs() []int {
+	var procs []int
+	for n := 1; n < runtime.NumCPU(); n *= 2 {
+		procs = append(procs, n)
+	}
+	return append(procs, runtime.NumCPU())
+}
+
+type parallelOp struct {
+	name string
+	run  func(data []byte, i int) error
+}
+
+// parallelOps are every library's get and decode on the example corpus,
+// plus the struct decoders that share a type cache between goroutines.
+var parallelOps = func() []parallelOp {
+	var ops []parallelOp
+	for _, lib := range libraries {
+		lib := lib
+		ops = append(ops,
+			parallelOp{lib.name + "/get", func(data []byte, i int) error {
+				_, err := lib.get(data, benchPaths[i%len(benchPaths)])
+				return err
+			}},
+			parallelOp{lib.name + "/decode", func(data []byte, _ int) error {
+				_, err := lib.decode(data)
+				return err
+			}},
+		)
+	}
+	return append(ops,
+		parallelOp{"encoding/json/struct", func(data []byte, _ int) error {
+			var v widgetIn
+			return json.Unmarshal(data, &v)
+		}},
+		parallelOp{"jsoniter/struct", func(data []byte, _ int) error {
+			var v widgetIn
+			return jsoniter.Unmarshal(data, &v)
+		}},
+		parallelOp{"easyjson/struct", func(data []byte, _ int) error {
+			var v widgetIn
+			l := jlexer.Lexer{Data: data}
+			v.UnmarshalEasyJSON(&l)
+			return l.Error()
+		}},
+	)
+}()
+
+// withProcs runs f with GOMAXPROCS set to n.
+func withProcs(n int, f func()) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(n))
+	f()
+}
+
+func BenchmarkParallel(b *testing.B) {
+	data := []byte(exampleJSON)
+	for _, op := range parallelOps {
+		b.Run(op.name, func(b *testing.B) {
+			for _, n := range parallelProcs() {
+				b.Run("procs="+strconv.Itoa(n), func(b *testing.B) {
+					withProcs(n, func() {
+						b.ReportAllocs()
+						b.ResetTimer()
+						defer reportMetrics(b)()
+						b.RunParallel(func(pb *testing.PB) {
+							var i int
+							for pb.Next() {
+								if err := op.run(data, i); err != nil {
+									// Fatal must not be called from the workers
+									b.Error(err)
+									return
+								}
+								i++
+							}
+						})
+					})
+				})
+			}
+		})
+	}
+}
+
+// parallelThroughput runs f on procs goroutines, with GOMAXPROCS set to
+// match, for about d and returns the operations per second.
+func parallelThroughput(procs int, d time.Duration, f func(i int)) float64 {
+	var ops int64
+	withProcs(procs, func() {
+		var stop int32
+		var wg sync.WaitGroup
+		start := time.Now()
+		for p := 0; p < procs; p++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var n int64
+				for ; atomic.LoadInt32(&stop) == 0; n++ {
+					f(int(n))
+				}
+				atomic.AddInt64(&ops, n)
+			}()
+		}
+		time.Sleep(d)
+		atomic.StoreInt32(&stop, 1)
+		wg.Wait()
+		d = time.Since(start)
+	})
+	return float64(ops) / d.Seconds()
+}
+
+func TestParallelScaling(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs every operation at every GOMAXPROCS")
+	}
+	data := []byte(exampleJSON)
+	procs := parallelProcs()
+	header := []string{"operation", "1 proc"}
+	for _, n := range procs[1:] {
+		header = append(header, strconv.Itoa(n)+" procs")
+	}
+	tbl := newTable(fmt.Sprintf("Scaling efficiency (speedup / procs), NumCPU=%d", runtime.NumCPU()),
+		header...)
+	for _, op := range parallelOps {
+		if err := op.run(data, 0); err != nil {
+			t.Fatalf("%s: %v", op.name, err)
+		}
+		run := func(i int) { op.run(data, i) }
+		warmup(100*time.Millisecond, func() { run(0) })
+		var base, baseCV float64
+		row := []string{op.name}
+		for _, n := range procs {
+			tput, cv := repeat(func() float64 {
+				return parallelThroughput(n, 100*time.Millisecond/time.Duration(repCount()), run)
+			})
+			if n == 1 {
+				base, baseCV = tput, cv
+				row = append(row, tbl.timing(sample{time.Duration(float64(time.Second) / tput), cv})+"/op")
+				continue
+			}
+			row = append(row, tbl.mark(fmt.Sprintf("%.0f%%", 100*tput/base/float64(n)), math.Max(cv, baseCV)))
+		}
+		tbl.add(row...)
+	}
+	publish(t, tbl)
+}
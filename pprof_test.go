@@ -0,0 +1,348 @@
+package gjson_benchmarks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// pprofProfile is the part of a pprof profile.proto that the summaries
+// need: samples, the functions on their stacks, and the string table.
+type pprofProfile struct {
+	sampleTypes []int64 // string table indexes
+	samples     []pprofSample
+	locations   map[uint64][]uint64 // function ids, innermost first
+	functions   map[uint64]int64    // string table index of the name
+	strings     []string
+}
+
+type pprofSample struct {
+	locations []uint64 // leaf first
+	values    []int64
+}
+
+var errProto = errors.New("malformed protobuf")
+
+// pbReader reads the protobuf wire format, just enough of it for pprof.
+type pbReader struct {
+	b   []byte
+	err error
+}
+
+func (r *pbReader) varint() uint64 {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if len(r.b) == 0 {
+			r.err = errProto
+			return 0
+		}
+		c := r.b[0]
+		r.b = r.b[1:]
+		v |= uint64(c&0x7f) << shift
+		if c < 0x80 {
+			return v
+		}
+	}
+	r.err = errProto
+	return 0
+}
+
+func (r *pbReader) bytes() []byte {
+	n := r.varint()
+	if r.err != nil || n > uint64(len(r.b)) {
+		r.err = errProto
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+// field returns the next field number and wire type.
+func (r *pbReader) field() (int, int, bool) {
+	if len(r.b) == 0 || r.err != nil {
+		return 0, 0, false
+	}
+	key := r.varint()
+	return int(key >> 3), int(key & 7), r.err == nil
+}
+
+func (r *pbReader) skip(wire int) {
+	switch wire {
+	case 0:
+		r.varint()
+	case 1:
+		r.fixed(8)
+	case 2:
+		r.bytes()
+	case 5:
+		r.fixed(4)
+	default:
+		r.err = errProto
+	}
+}
+
+func (r *pbReader) fixed(n int) {
+	if len(r.b) < n {
+		r.err = errProto
+		return
+	}
+	r.b = r.b[n:]
+}
+
+// uints reads a repeated integer field, packed or not.
+func (r *pbReader) uints(wire int, dst []uint64) []uint64 {
+	if wire != 2 {
+		return append(dst, r.varint())
+	}
+	packed := pbReader{b: r.bytes()}
+	for len(packed.b) > 0 && packed.err == nil {
+		dst = append(dst, packed.varint())
+	}
+	if packed.err != nil {
+		r.err = packed.err
+	}
+	return dst
+}
+
+// parsePprof decodes a gzipped profile as written by runtime/pprof.
+func parsePprof(data []byte) (*pprofProfile, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	p := &pprofProfile{
+		locations: map[uint64][]uint64{},
+		functions: map[uint64]int64{},
+	}
+	r := &pbReader{b: raw}
+	for {
+		num, wire, ok := r.field()
+		if !ok {
+			break
+		}
+		switch num {
+		case 1: // sample_type
+			vt := pbReader{b: r.bytes()}
+			for {
+				n, w, ok := vt.field()
+				if !ok {
+					break
+				}
+				if n == 1 {
+					p.sampleTypes = append(p.sampleTypes, int64(vt.varint()))
+				} else {
+					vt.skip(w)
+				}
+			}
+		case 2: // sample
+			p.samples = append(p.samples, parsePprofSample(r.bytes()))
+		case 4: // location
+			id, funcs := parsePprofLocation(r.bytes())
+			p.locations[id] = funcs
+		case 5: // function
+			id, name := parsePprofFunction(r.bytes())
+			p.functions[id] = name
+		case 6: // string_table
+			p.strings = append(p.strings, string(r.bytes()))
+		default:
+			r.skip(wire)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return p, nil
+}
+
+func parsePprofSample(b []byte) pprofSample {
+	var s pprofSample
+	r := pbReader{b: b}
+	for {
+		num, wire, ok := r.field()
+		if !ok {
+			break
+		}
+		switch num {
+		case 1:
+			s.locations = r.uints(wire, s.locations)
+		case 2:
+			for _, v := range r.uints(wire, nil) {
+				s.values = append(s.values, int64(v))
+			}
+		default:
+			r.skip(wire)
+		}
+	}
+	return s
+}
+
+func parsePprofLocation(b []byte) (uint64, []uint64) {
+	var id uint64
+	var funcs []uint64
+	r := pbReader{b: b}
+	for {
+		num, wire, ok := r.field()
+		if !ok {
+			break
+		}
+		switch num {
+		case 1:
+			id = r.varint()
+		case 4: // line
+			line := pbReader{b: r.bytes()}
+			for {
+				n, w, ok := line.field()
+				if !ok {
+					break
+				}
+				if n == 1 {
+					funcs = append(funcs, line.varint())
+				} else {
+					line.skip(w)
+				}
+			}
+		default:
+			r.skip(wire)
+		}
+	}
+	return id, funcs
+}
+
+func parsePprofFunction(b []byte) (uint64, int64) {
+	var id uint64
+	var name int64
+	r := pbReader{b: b}
+	for {
+		num, wire, ok := r.field()
+		if !ok {
+			break
+		}
+		switch num {
+		case 1:
+			id = r.varint()
+		case 2:
+			name = int64(r.varint())
+		default:
+			r.skip(wire)
+		}
+	}
+	return id, name
+}
+
+func (p *pprofProfile) str(i int64) string {
+	if i < 0 || i >= int64(len(p.strings)) {
+		return ""
+	}
+	return p.strings[i]
+}
+
+// funcCost is the flat and cumulative value of one function.
+type funcCost struct {
+	name      string
+	flat, cum int64
+}
+
+// costs sums the named sample type per function. Flat is charged to the
+// innermost frame and cum to every function on the stack, once. If root
+// is set, only samples under a function with that suffix count, and only
+// the frames it called.
+func (p *pprofProfile) costs(sampleType, root string) (map[string]*funcCost, error) {
+	idx := -1
+	for i, t := range p.sampleTypes {
+		if p.str(t) == sampleType {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("no %q samples in profile", sampleType)
+	}
+	costs := map[string]*funcCost{}
+	cost := func(name string) *funcCost {
+		c := costs[name]
+		if c == nil {
+			c = &funcCost{name: name}
+			costs[name] = c
+		}
+		return c
+	}
+	var stack []string
+	for _, s := range p.samples {
+		if idx >= len(s.values) {
+			continue
+		}
+		stack = stack[:0]
+		for _, loc := range s.locations {
+			for _, fn := range p.locations[loc] {
+				stack = append(stack, p.str(p.functions[fn]))
+			}
+		}
+		if root != "" {
+			var found bool
+			for i, name := range stack {
+				if strings.HasSuffix(name, root) {
+					stack, found = stack[:i], true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		if len(stack) == 0 {
+			continue
+		}
+		v := s.values[idx]
+		cost(stack[0]).flat += v
+		seen := map[string]bool{}
+		for _, name := range stack {
+			if !seen[name] {
+				seen[name] = true
+				cost(name).cum += v
+			}
+		}
+	}
+	return costs, nil
+}
+
+// shortFuncName drops the import path, keeping package.Func.
+func shortFuncName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// topCosts returns the n most expensive functions by flat or cum value.
+func topCosts(costs map[string]*funcCost, n int, byCum bool) []*funcCost {
+	key := func(c *funcCost) int64 {
+		if byCum {
+			return c.cum
+		}
+		return c.flat
+	}
+	var all []*funcCost
+	for _, c := range costs {
+		if key(c) > 0 {
+			all = append(all, c)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if key(all[i]) != key(all[j]) {
+			return key(all[i]) > key(all[j])
+		}
+		return all[i].name < all[j].name
+	})
+	if len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
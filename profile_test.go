@@ -0,0 +1,246 @@
+package gjson_benchmarks
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	profileEnabled = flag.Bool("profile", false,
+		"profile every library operation on every corpus and report the top functions")
+	profileTop = flag.Int("profile.top", 10,
+		"number of functions to report per profile")
+	profileTime = flag.Duration("profile.time", time.Second,
+		"how long to run each operation under the CPU profiler")
+	profileDir = flag.String("profile.dir", "",
+		"also write the raw profiles to this directory")
+)
+
+// profileMemRate samples allocations finely enough for operations that
+// allocate a few hundred bytes.
+const profileMemRate = 4096
+
+// profileRoot is the frame that the summaries are cut at, so that they
+// leave out the harness and the profiler itself.
+const profileRoot = ".profileLoop"
+
+// profileLoop runs f at least once and for d. It also marks the frames
+// that the summaries keep.
+func profileLoop(d time.Duration, f func()) {
+	for start := time.Now(); ; {
+		f()
+		if time.Since(start) >= d {
+			return
+		}
+	}
+}
+
+// captureCPU runs f under the CPU profiler for d.
+func captureCPU(d time.Duration, f func()) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, err
+	}
+	profileLoop(d, f)
+	pprof.StopCPUProfile()
+	return buf.Bytes(), nil
+}
+
+// captureAllocs writes the allocation profile, which counts everything
+// since the program started, after the last allocations are flushed.
+func captureAllocs() ([]byte, error) {
+	runtime.GC()
+	var buf bytes.Buffer
+	if err := pprof.Lookup("allocs").WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// profileCosts parses a profile and sums one sample type per function.
+func profileCosts(data []byte, sampleType string) (map[string]*funcCost, error) {
+	p, err := parsePprof(data)
+	if err != nil {
+		return nil, err
+	}
+	return p.costs(sampleType, profileRoot)
+}
+
+// subtractCosts leaves what was allocated between two allocs profiles.
+func subtractCosts(after, before map[string]*funcCost) map[string]*funcCost {
+	for name, b := range before {
+		if a, ok := after[name]; ok {
+			a.flat -= b.flat
+			a.cum -= b.cum
+		}
+	}
+	return after
+}
+
+func totalFlat(costs map[string]*funcCost) int64 {
+	var total int64
+	for _, c := range costs {
+		total += c.flat
+	}
+	return total
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
+
+func writeProfile(name string, data []byte) error {
+	if *profileDir == "" {
+		return nil
+	}
+	name = strings.NewReplacer("/", "_", " ", "_").Replace(name)
+	return ioutil.WriteFile(filepath.Join(*profileDir, name), data, 0644)
+}
+
+// profileColumn formats the top functions of one profile as cells.
+func profileColumn(costs map[string]*funcCost, byCum bool) []string {
+	total := totalFlat(costs)
+	var cells []string
+	for _, c := range topCosts(costs, *profileTop, byCum) {
+		v := c.flat
+		if byCum {
+			v = c.cum
+		}
+		pct := 0.0
+		if total > 0 {
+			pct = 100 * float64(v) / float64(total)
+		}
+		cells = append(cells, fmt.Sprintf("%s %.1f%%", shortFuncName(c.name), pct))
+	}
+	return cells
+}
+
+func TestProfiles(t *testing.T) {
+	if !*profileEnabled {
+		t.Skip("enable with -profile")
+	}
+	defer func(rate int) { runtime.MemProfileRate = rate }(runtime.MemProfileRate)
+	runtime.MemProfileRate = profileMemRate
+	for _, lib := range libraries {
+		for _, c := range convertCases {
+			data := []byte(c.json)
+			for _, op := range latencyOps {
+				if err := op.run(lib, data, c.path); err != nil {
+					continue
+				}
+				run := func() { op.run(lib, data, c.path) }
+				name := lib.name + " " + c.name + " " + op.name
+
+				cpuProf, err := captureCPU(*profileTime, run)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cpu, err := profileCosts(cpuProf, "cpu")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				before, err := captureAllocs()
+				if err != nil {
+					t.Fatal(err)
+				}
+				profileLoop(*profileTime/4, run)
+				after, err := captureAllocs()
+				if err != nil {
+					t.Fatal(err)
+				}
+				allocsBefore, err := profileCosts(before, "alloc_space")
+				if err != nil {
+					t.Fatal(err)
+				}
+				allocsAfter, err := profileCosts(after, "alloc_space")
+				if err != nil {
+					t.Fatal(err)
+				}
+				allocs := subtractCosts(allocsAfter, allocsBefore)
+
+				if err := writeProfile(name+".cpu.pb.gz", cpuProf); err != nil {
+					t.Fatal(err)
+				}
+				if err := writeProfile(name+".allocs.pb.gz", after); err != nil {
+					t.Fatal(err)
+				}
+
+				tbl := newTable(fmt.Sprintf("Profile: %s (cpu %s, alloc %s)", name,
+					roundDuration(time.Duration(totalFlat(cpu))), formatBytes(totalFlat(allocs))),
+					"#", "cpu flat", "cpu cum", "alloc flat", "alloc cum")
+				cols := [][]string{
+					profileColumn(cpu, false), profileColumn(cpu, true),
+					profileColumn(allocs, false), profileColumn(allocs, true),
+				}
+				for i := 0; i < *profileTop; i++ {
+					row := []string{strconv.Itoa(i + 1)}
+					var any bool
+					for _, col := range cols {
+						cell := ""
+						if i < len(col) {
+							cell, any = col[i], true
+						}
+						row = append(row, cell)
+					}
+					if !any {
+						break
+					}
+					tbl.add(row...)
+				}
+				publish(t, tbl)
+			}
+		}
+	}
+}
+
+var profileSink [][]byte
+
+func TestPprofParse(t *testing.T) {
+	defer func(rate int) { runtime.MemProfileRate = rate }(runtime.MemProfileRate)
+	runtime.MemProfileRate = profileMemRate
+	profileLoop(0, func() {
+		for i := 0; i < 100; i++ {
+			profileSink = append(profileSink, make([]byte, 64<<10))
+		}
+	})
+	profileSink = nil
+	data, err := captureAllocs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	costs, err := profileCosts(data, "alloc_space")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for name, c := range costs {
+		if strings.Contains(name, ".TestPprofParse.") && c.flat >= 100*64<<10 {
+			found = true
+		}
+		if c.cum < c.flat {
+			t.Fatalf("%s: cum %d below flat %d", name, c.cum, c.flat)
+		}
+	}
+	if !found {
+		t.Fatal("allocations under profileLoop missing from the profile")
+	}
+	if _, err := profileCosts(data, "cpu"); err == nil {
+		t.Fatal("expected an error for a missing sample type")
+	}
+}
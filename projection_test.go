@@ -0,0 +1,479 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mailru/easyjson/jlexer"
+	"github.com/mailru/easyjson/jwriter"
+	"github.com/tidwall/gjson"
+)
+
+type widgetIn struct {
+	Widget struct {
+		Window struct {
+			Title  string `json:"title"`
+			Name   string `json:"name"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"window"`
+		Image struct {
+			Src     string `json:"src"`
+			HOffset int    `json:"hOffset"`
+		} `json:"image"`
+		Text struct {
+			Data      string `json:"data"`
+			OnMouseUp string `json:"onMouseUp"`
+		} `json:"text"`
+	} `json:"widget"`
+}
+
+type widgetOut struct {
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Src       string `json:"src"`
+	Off       int    `json:"off"`
+	Data      string `json:"data"`
+	OnMouseUp string `json:"onMouseUp"`
+}
+
+const widgetObjectPath = `{name:widget.window.name,title:widget.window.title,` +
+	`width:widget.window.width,height:widget.window.height,` +
+	`src:widget.image.src,off:widget.image.hOffset,` +
+	`data:widget.text.data,onMouseUp:widget.text.onMouseUp}`
+
+const widgetArrayPath = `[widget.window.name,widget.window.title,` +
+	`widget.window.width,widget.window.height,` +
+	`widget.image.src,widget.image.hOffset,` +
+	`widget.text.data,widget.text.onMouseUp]`
+
+func (v *widgetIn) project() widgetOut {
+	w := &v.Widget
+	return widgetOut{
+		Name: w.Window.Name, Title: w.Window.Title,
+		Width: w.Window.Width, Height: w.Window.Height,
+		Src: w.Image.Src, Off: w.Image.HOffset,
+		Data: w.Text.Data, OnMouseUp: w.Text.OnMouseUp,
+	}
+}
+
+func (o *widgetOut) array() []interface{} {
+	return []interface{}{
+		o.Name, o.Title, o.Width, o.Height,
+		o.Src, o.Off, o.Data, o.OnMouseUp,
+	}
+}
+
+type statusIn struct {
+	IDStr     string `json:"id_str"`
+	CreatedAt string `json:"created_at"`
+	Text      string `json:"text"`
+	User      struct {
+		ScreenName     string `json:"screen_name"`
+		FollowersCount int    `json:"followers_count"`
+	} `json:"user"`
+	RetweetCount int    `json:"retweet_count"`
+	Lang         string `json:"lang"`
+	Metadata     struct {
+		ISOLanguageCode string `json:"iso_language_code"`
+	} `json:"metadata"`
+}
+
+type statusesIn struct {
+	Statuses []statusIn `json:"statuses"`
+}
+
+type statusOut struct {
+	ID        string `json:"id"`
+	Created   string `json:"created"`
+	Text      string `json:"text"`
+	User      string `json:"user"`
+	Followers int    `json:"followers"`
+	Retweets  int    `json:"retweets"`
+	Lang      string `json:"lang"`
+	ISO       string `json:"iso"`
+}
+
+const statusesObjectPath = `statuses.#.{id:id_str,created:created_at,text,` +
+	`user:user.screen_name,followers:user.followers_count,` +
+	`retweets:retweet_count,lang,iso:metadata.iso_language_code}`
+
+func (v *statusesIn) project() []statusOut {
+	out := make([]statusOut, len(v.Statuses))
+	for i, s := range v.Statuses {
+		out[i] = statusOut{
+			ID: s.IDStr, Created: s.CreatedAt, Text: s.Text,
+			User: s.User.ScreenName, Followers: s.User.FollowersCount,
+			Retweets: s.RetweetCount, Lang: s.Lang,
+			ISO: s.Metadata.ISOLanguageCode,
+		}
+	}
+	return out
+}
+
+// The easyjson methods below are written the way easyjson generates them,
+// as this repo does not use code generation.
+
+// easyjsonObject calls field for every member of the next object.
+func easyjsonObject(l *jlexer.Lexer, field func(key string)) {
+	if l.IsNull() {
+		l.Skip()
+		return
+	}
+	l.Delim('{')
+	for !l.IsDelim('}') {
+		key := l.UnsafeFieldName(false)
+		l.WantColon()
+		if l.IsNull() {
+			l.Skip()
+		} else {
+			field(key)
+		}
+		l.WantComma()
+	}
+	l.Delim('}')
+}
+
+func (v *widgetIn) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	w := &v.Widget
+	easyjsonObject(l, func(key string) {
+		if key != "widget" {
+			l.SkipRecursive()
+			return
+		}
+		easyjsonObject(l, func(key string) {
+			switch key {
+			case "window":
+				easyjsonObject(l, func(key string) {
+					switch key {
+					case "title":
+						w.Window.Title = l.String()
+					case "name":
+						w.Window.Name = l.String()
+					case "width":
+						w.Window.Width = l.Int()
+					case "height":
+						w.Window.Height = l.Int()
+					default:
+						l.SkipRecursive()
+					}
+				})
+			case "image":
+				easyjsonObject(l, func(key string) {
+					switch key {
+					case "src":
+						w.Image.Src = l.String()
+					case "hOffset":
+						w.Image.HOffset = l.Int()
+					default:
+						l.SkipRecursive()
+					}
+				})
+			case "text":
+				easyjsonObject(l, func(key string) {
+					switch key {
+					case "data":
+						w.Text.Data = l.String()
+					case "onMouseUp":
+						w.Text.OnMouseUp = l.String()
+					default:
+						l.SkipRecursive()
+					}
+				})
+			default:
+				l.SkipRecursive()
+			}
+		})
+	})
+}
+
+func (o *widgetOut) MarshalEasyJSON(w *jwriter.Writer) {
+	w.RawString(`{"name":`)
+	w.String(o.Name)
+	w.RawString(`,"title":`)
+	w.String(o.Title)
+	w.RawString(`,"width":`)
+	w.Int(o.Width)
+	w.RawString(`,"height":`)
+	w.Int(o.Height)
+	w.RawString(`,"src":`)
+	w.String(o.Src)
+	w.RawString(`,"off":`)
+	w.Int(o.Off)
+	w.RawString(`,"data":`)
+	w.String(o.Data)
+	w.RawString(`,"onMouseUp":`)
+	w.String(o.OnMouseUp)
+	w.RawByte('}')
+}
+
+func (o *widgetOut) marshalEasyJSONArray(w *jwriter.Writer) {
+	w.RawByte('[')
+	w.String(o.Name)
+	w.RawByte(',')
+	w.String(o.Title)
+	w.RawByte(',')
+	w.Int(o.Width)
+	w.RawByte(',')
+	w.Int(o.Height)
+	w.RawByte(',')
+	w.String(o.Src)
+	w.RawByte(',')
+	w.Int(o.Off)
+	w.RawByte(',')
+	w.String(o.Data)
+	w.RawByte(',')
+	w.String(o.OnMouseUp)
+	w.RawByte(']')
+}
+
+func (v *statusesIn) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonObject(l, func(key string) {
+		if key != "statuses" {
+			l.SkipRecursive()
+			return
+		}
+		l.Delim('[')
+		for !l.IsDelim(']') {
+			v.Statuses = append(v.Statuses, statusIn{})
+			s := &v.Statuses[len(v.Statuses)-1]
+			easyjsonObject(l, func(key string) {
+				switch key {
+				case "id_str":
+					s.IDStr = l.String()
+				case "created_at":
+					s.CreatedAt = l.String()
+				case "text":
+					s.Text = l.String()
+				case "retweet_count":
+					s.RetweetCount = l.Int()
+				case "lang":
+					s.Lang = l.String()
+				case "user":
+					easyjsonObject(l, func(key string) {
+						switch key {
+						case "screen_name":
+							s.User.ScreenName = l.String()
+						case "followers_count":
+							s.User.FollowersCount = l.Int()
+						default:
+							l.SkipRecursive()
+						}
+					})
+				case "metadata":
+					easyjsonObject(l, func(key string) {
+						if key == "iso_language_code" {
+							s.Metadata.ISOLanguageCode = l.String()
+						} else {
+							l.SkipRecursive()
+						}
+					})
+				default:
+					l.SkipRecursive()
+				}
+			})
+			l.WantComma()
+		}
+		l.Delim(']')
+	})
+}
+
+func (o *statusOut) MarshalEasyJSON(w *jwriter.Writer) {
+	w.RawString(`{"id":`)
+	w.String(o.ID)
+	w.RawString(`,"created":`)
+	w.String(o.Created)
+	w.RawString(`,"text":`)
+	w.String(o.Text)
+	w.RawString(`,"user":`)
+	w.String(o.User)
+	w.RawString(`,"followers":`)
+	w.Int(o.Followers)
+	w.RawString(`,"retweets":`)
+	w.Int(o.Retweets)
+	w.RawString(`,"lang":`)
+	w.String(o.Lang)
+	w.RawString(`,"iso":`)
+	w.String(o.ISO)
+	w.RawByte('}')
+}
+
+type projector struct {
+	name    string
+	project func(data []byte) ([]byte, error)
+}
+
+type projectionCase struct {
+	name       string
+	json       string
+	projectors []projector
+}
+
+var projectionCases = []projectionCase{
+	{"example/object", exampleJSON, []projector{
+		{"gjson", func(data []byte) ([]byte, error) {
+			return []byte(gjson.GetBytes(data, widgetObjectPath).Raw), nil
+		}},
+		{"encoding/json", func(data []byte) ([]byte, error) {
+			var v widgetIn
+			if err := json.Unmarshal(data, &v); err != nil {
+				return nil, err
+			}
+			out := v.project()
+			return json.Marshal(&out)
+		}},
+		{"jsoniter", func(data []byte) ([]byte, error) {
+			var v widgetIn
+			if err := jsoniter.Unmarshal(data, &v); err != nil {
+				return nil, err
+			}
+			out := v.project()
+			return jsoniter.Marshal(&out)
+		}},
+		{"easyjson", func(data []byte) ([]byte, error) {
+			var v widgetIn
+			l := jlexer.Lexer{Data: data}
+			v.UnmarshalEasyJSON(&l)
+			if err := l.Error(); err != nil {
+				return nil, err
+			}
+			var w jwriter.Writer
+			out := v.project()
+			out.MarshalEasyJSON(&w)
+			return w.BuildBytes()
+		}},
+	}},
+	{"example/array", exampleJSON, []projector{
+		{"gjson", func(data []byte) ([]byte, error) {
+			return []byte(gjson.GetBytes(data, widgetArrayPath).Raw), nil
+		}},
+		{"encoding/json", func(data []byte) ([]byte, error) {
+			var v widgetIn
+			if err := json.Unmarshal(data, &v); err != nil {
+				return nil, err
+			}
+			out := v.project()
+			return json.Marshal(out.array())
+		}},
+		{"jsoniter", func(data []byte) ([]byte, error) {
+			var v widgetIn
+			if err := jsoniter.Unmarshal(data, &v); err != nil {
+				return nil, err
+			}
+			out := v.project()
+			return jsoniter.Marshal(out.array())
+		}},
+		{"easyjson", func(data []byte) ([]byte, error) {
+			var v widgetIn
+			l := jlexer.Lexer{Data: data}
+			v.UnmarshalEasyJSON(&l)
+			if err := l.Error(); err != nil {
+				return nil, err
+			}
+			var w jwriter.Writer
+			out := v.project()
+			out.marshalEasyJSONArray(&w)
+			return w.BuildBytes()
+		}},
+	}},
+	{"statuses/object", twitterLarge, []projector{
+		{"gjson", func(data []byte) ([]byte, error) {
+			return []byte(gjson.GetBytes(data, statusesObjectPath).Raw), nil
+		}},
+		{"encoding/json", func(data []byte) ([]byte, error) {
+			var v statusesIn
+			if err := json.Unmarshal(data, &v); err != nil {
+				return nil, err
+			}
+			return json.Marshal(v.project())
+		}},
+		{"jsoniter", func(data []byte) ([]byte, error) {
+			var v statusesIn
+			if err := jsoniter.Unmarshal(data, &v); err != nil {
+				return nil, err
+			}
+			return jsoniter.Marshal(v.project())
+		}},
+		{"easyjson", func(data []byte) ([]byte, error) {
+			var v statusesIn
+			l := jlexer.Lexer{Data: data}
+			v.UnmarshalEasyJSON(&l)
+			if err := l.Error(); err != nil {
+				return nil, err
+			}
+			var w jwriter.Writer
+			w.RawByte('[')
+			for i, out := range v.project() {
+				if i > 0 {
+					w.RawByte(',')
+				}
+				out.MarshalEasyJSON(&w)
+			}
+			w.RawByte(']')
+			return w.BuildBytes()
+		}},
+	}},
+}
+
+// checkProjection compares the output with the encoding/json projection,
+// which all outputs must be semantically equal to.
+func checkProjection(pc projectionCase, p projector, out []byte) error {
+	var want []byte
+	err := fmt.Errorf("%s: no encoding/json projector", pc.name)
+	for _, ref := range pc.projectors {
+		if ref.name == "encoding/json" {
+			want, err = ref.project([]byte(pc.json))
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if got, want := canonicalRaw(out), canonicalRaw(want); got != want {
+		return fmt.Errorf("%s: %s: got %s, expected %s", pc.name, p.name, got, want)
+	}
+	return nil
+}
+
+func TestProjectionEqual(t *testing.T) {
+	for _, pc := range projectionCases {
+		for _, p := range pc.projectors {
+			out, err := p.project([]byte(pc.json))
+			if err == nil {
+				err = checkProjection(pc, p, out)
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func BenchmarkProjection(b *testing.B) {
+	for _, pc := range projectionCases {
+		data := []byte(pc.json)
+		b.Run(pc.name, func(b *testing.B) {
+			for _, p := range pc.projectors {
+				b.Run(p.name, func(b *testing.B) {
+					var out []byte
+					var err error
+					b.ReportAllocs()
+					stopMetrics := reportMetrics(b)
+					for i := 0; i < b.N; i++ {
+						if out, err = p.project(data); err != nil {
+							b.Fatal(err)
+						}
+					}
+					stopMetrics()
+					if err := checkProjection(pc, p, out); err != nil {
+						b.Fatal(err)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+// queryStatus has every field that the hand-written filters look at.
+type queryStatus struct {
+	IDStr        string `json:"id_str"`
+	RetweetCount int    `json:"retweet_count"`
+	Metadata     struct {
+		ISOLanguageCode string `json:"iso_language_code"`
+	} `json:"metadata"`
+	User struct {
+		ScreenName     string `json:"screen_name"`
+		FriendsCount   int    `json:"friends_count"`
+		FollowersCount int    `json:"followers_count"`
+	} `json:"user"`
+	Entities struct {
+		Hashtags []struct {
+			Text string `json:"text"`
+		} `json:"hashtags"`
+	} `json:"entities"`
+}
+
+// queryCase is a gjson query over twitterLarge along with the same filter
+// written by hand. Every query selects the "id_str" of matching statuses.
+type queryCase struct {
+	name  string
+	path  string
+	first bool // only the first match
+	match func(s *queryStatus) bool
+}
+
+var queryCases = []queryCase{
+	{"first/number", `statuses.#(user.friends_count>1000).id_str`, true,
+		func(s *queryStatus) bool { return s.User.FriendsCount > 1000 }},
+	{"first/string", `statuses.#(metadata.iso_language_code=="zh").id_str`, true,
+		func(s *queryStatus) bool { return s.Metadata.ISOLanguageCode == "zh" }},
+	{"all/number>", `statuses.#(user.friends_count>1000)#.id_str`, false,
+		func(s *queryStatus) bool { return s.User.FriendsCount > 1000 }},
+	{"all/number>=", `statuses.#(user.followers_count>=100)#.id_str`, false,
+		func(s *queryStatus) bool { return s.User.FollowersCount >= 100 }},
+	{"all/number<=", `statuses.#(retweet_count<=0)#.id_str`, false,
+		func(s *queryStatus) bool { return s.RetweetCount <= 0 }},
+	{"all/string==", `statuses.#(metadata.iso_language_code=="zh")#.id_str`, false,
+		func(s *queryStatus) bool { return s.Metadata.ISOLanguageCode == "zh" }},
+	{"all/string<", `statuses.#(user.screen_name<"m")#.id_str`, false,
+		func(s *queryStatus) bool { return s.User.ScreenName < "m" }},
+	{"all/match", `statuses.#(user.screen_name%"*bot*")#.id_str`, false,
+		func(s *queryStatus) bool { return strings.Contains(s.User.ScreenName, "bot") }},
+	{"all/notmatch", `statuses.#(user.screen_name!%"*_*")#.id_str`, false,
+		func(s *queryStatus) bool { return !strings.Contains(s.User.ScreenName, "_") }},
+	{"all/nested", `statuses.#(entities.hashtags.#(text%"*"))#.id_str`, false,
+		func(s *queryStatus) bool { return len(s.Entities.Hashtags) > 0 }},
+}
+
+func gjsonQuery(data []byte, q queryCase, ids []string) []string {
+	res := gjson.GetBytes(data, q.path)
+	if q.first {
+		if res.Exists() {
+			ids = append(ids, res.String())
+		}
+		return ids
+	}
+	res.ForEach(func(_, value gjson.Result) bool {
+		ids = append(ids, value.String())
+		return true
+	})
+	return ids
+}
+
+var queryStatusPaths = [][]string{
+	{"id_str"},
+	{"retweet_count"},
+	{"metadata", "iso_language_code"},
+	{"user", "screen_name"},
+	{"user", "friends_count"},
+	{"user", "followers_count"},
+	{"entities", "hashtags"},
+}
+
+func jsonparserQueryStatus(value []byte, s *queryStatus) {
+	*s = queryStatus{}
+	jsonparser.EachKey(value, func(idx int, value []byte, _ jsonparser.ValueType, _ error) {
+		switch idx {
+		case 0:
+			s.IDStr, _ = jsonparser.ParseString(value)
+		case 1:
+			n, _ := jsonparser.ParseInt(value)
+			s.RetweetCount = int(n)
+		case 2:
+			s.Metadata.ISOLanguageCode, _ = jsonparser.ParseString(value)
+		case 3:
+			s.User.ScreenName, _ = jsonparser.ParseString(value)
+		case 4:
+			n, _ := jsonparser.ParseInt(value)
+			s.User.FriendsCount = int(n)
+		case 5:
+			n, _ := jsonparser.ParseInt(value)
+			s.User.FollowersCount = int(n)
+		case 6:
+			jsonparser.ArrayEach(value, func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+				text, _ := jsonparser.GetString(value, "text")
+				s.Entities.Hashtags = append(s.Entities.Hashtags, struct {
+					Text string `json:"text"`
+				}{text})
+			})
+		}
+	}, queryStatusPaths...)
+}
+
+func jsonparserQuery(data []byte, q queryCase, ids []string) []string {
+	var s queryStatus
+	done := false
+	jsonparser.ArrayEach(data, func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+		if done {
+			// ArrayEach has no way to stop early
+			return
+		}
+		jsonparserQueryStatus(value, &s)
+		if q.match(&s) {
+			ids = append(ids, s.IDStr)
+			done = q.first
+		}
+	}, "statuses")
+	return ids
+}
+
+func jsoniterQuery(data []byte, q queryCase, ids []string) []string {
+	iter := jsoniter.ConfigDefault.BorrowIterator(data)
+	defer jsoniter.ConfigDefault.ReturnIterator(iter)
+	for key := iter.ReadObject(); key != ""; key = iter.ReadObject() {
+		if key != "statuses" {
+			iter.Skip()
+			continue
+		}
+		for iter.ReadArray() {
+			var s queryStatus
+			iter.ReadVal(&s)
+			if q.match(&s) {
+				ids = append(ids, s.IDStr)
+				if q.first {
+					return ids
+				}
+			}
+		}
+	}
+	return ids
+}
+
+func structQuery(data []byte, q queryCase, ids []string) []string {
+	var v struct {
+		Statuses []queryStatus `json:"statuses"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return ids
+	}
+	for i := range v.Statuses {
+		if q.match(&v.Statuses[i]) {
+			ids = append(ids, v.Statuses[i].IDStr)
+			if q.first {
+				break
+			}
+		}
+	}
+	return ids
+}
+
+var queryImpls = []struct {
+	name  string
+	query func(data []byte, q queryCase, ids []string) []string
+}{
+	{"gjson", gjsonQuery},
+	{"jsonparser", jsonparserQuery},
+	{"jsoniter", jsoniterQuery},
+	{"struct", structQuery},
+}
+
+func checkQuery(data []byte, q queryCase, ids []string) error {
+	want := structQuery(data, q, nil)
+	if len(want) == 0 {
+		return fmt.Errorf("%s: the query matches nothing", q.name)
+	}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		return fmt.Errorf("%s: got %d ids %v, expected %d ids %v",
+			q.name, len(ids), ids, len(want), want)
+	}
+	return nil
+}
+
+func TestQueryCrossCheck(t *testing.T) {
+	data := []byte(twitterLarge)
+	for _, q := range queryCases {
+		for _, impl := range queryImpls {
+			if err := checkQuery(data, q, impl.query(data, q, nil)); err != nil {
+				t.Errorf("%s: %v", impl.name, err)
+			}
+		}
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	data := []byte(twitterLarge)
+	for _, q := range queryCases {
+		b.Run(q.name, func(b *testing.B) {
+			for _, impl := range queryImpls {
+				b.Run(impl.name, func(b *testing.B) {
+					var ids []string
+					b.ReportAllocs()
+					b.ResetTimer()
+					stopMetrics := reportMetrics(b)
+					for i := 0; i < b.N; i++ {
+						ids = impl.query(data, q, ids[:0])
+					}
+					stopMetrics()
+					if err := checkQuery(data, q, ids); err != nil {
+						b.Fatal(err)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	"github.com/tidwall/gjson"
+)
+
+var bigArraySizes = []int{1000, 10000, 100000, 1000000}
+
+var (
+	bigArraysMu sync.Mutex
+	bigArrays   = map[int]string{}
+)
+
+// bigArray returns an object whose "arr" member holds n small objects,
+// where element i has the id i. It is generated on first use.
+func bigArray(n int) string {
+	bigArraysMu.Lock()
+	defer bigArraysMu.Unlock()
+	if s, ok := bigArrays[n]; ok {
+		return s
+	}
+	var sb strings.Builder
+	sb.WriteString(`{"arr":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `{"id":%d,"name":"item %d","tags":["a","b"]}`, i, i)
+	}
+	sb.WriteString("]}")
+	bigArrays[n] = sb.String()
+	return bigArrays[n]
+}
+
+// randomIndexes are the seeded indexes that every approach looks up.
+func randomIndexes(n int) []int {
+	rng := rand.New(rand.NewSource(42))
+	idxs := make([]int, 4096)
+	for i := range idxs {
+		idxs[i] = rng.Intn(n)
+	}
+	return idxs
+}
+
+type arrayElem struct {
+	ID int `json:"id"`
+}
+
+type arrayDoc struct {
+	Arr []arrayElem `json:"arr"`
+}
+
+// elementOffsets records where every element starts and ends, after a
+// single scan of the array.
+type elementOffsets struct {
+	starts, ends []int
+}
+
+func buildElementOffsets(data []byte) *elementOffsets {
+	var offs elementOffsets
+	gjson.GetBytes(data, "arr").ForEach(func(_, value gjson.Result) bool {
+		offs.starts = append(offs.starts, value.Index)
+		offs.ends = append(offs.ends, value.Index+len(value.Raw))
+		return true
+	})
+	return &offs
+}
+
+func (offs *elementOffsets) element(data []byte, i int) []byte {
+	if i < 0 || i >= len(offs.starts) {
+		return nil
+	}
+	return data[offs.starts[i]:offs.ends[i]]
+}
+
+// randomAccessors each return the id of element i. The prepare step runs
+// before timing, for the approaches that amortize a decode or scan.
+var randomAccessors = []struct {
+	name    string
+	prepare func(data []byte) interface{}
+	get     func(data []byte, prepared interface{}, i int) int64
+}{
+	{"gjson", nil, func(data []byte, _ interface{}, i int) int64 {
+		return gjson.GetBytes(data, "arr."+strconv.Itoa(i)+".id").Int()
+	}},
+	{"jsonparser", nil, func(data []byte, _ interface{}, i int) int64 {
+		n, _ := jsonparser.GetInt(data, "arr", "["+strconv.Itoa(i)+"]", "id")
+		return n
+	}},
+	{"slice/decode", nil, func(data []byte, _ interface{}, i int) int64 {
+		var doc arrayDoc
+		if json.Unmarshal(data, &doc) != nil || i >= len(doc.Arr) {
+			return -1
+		}
+		return int64(doc.Arr[i].ID)
+	}},
+	{"slice/prebuilt", func(data []byte) interface{} {
+		var doc arrayDoc
+		json.Unmarshal(data, &doc)
+		return doc.Arr
+	}, func(data []byte, prepared interface{}, i int) int64 {
+		return int64(prepared.([]arrayElem)[i].ID)
+	}},
+	{"offsets/build", nil, func(data []byte, _ interface{}, i int) int64 {
+		elem := buildElementOffsets(data).element(data, i)
+		return gjson.GetBytes(elem, "id").Int()
+	}},
+	{"offsets/prebuilt", func(data []byte) interface{} {
+		return buildElementOffsets(data)
+	}, func(data []byte, prepared interface{}, i int) int64 {
+		elem := prepared.(*elementOffsets).element(data, i)
+		return gjson.GetBytes(elem, "id").Int()
+	}},
+}
+
+func TestRandomIndex(t *testing.T) {
+	data := []byte(bigArray(1000))
+	for _, a := range randomAccessors {
+		var prepared interface{}
+		if a.prepare != nil {
+			prepared = a.prepare(data)
+		}
+		for _, i := range randomIndexes(1000)[:100] {
+			if id := a.get(data, prepared, i); id != int64(i) {
+				t.Fatalf("%s: element %d: got id %d", a.name, i, id)
+			}
+		}
+	}
+}
+
+func BenchmarkRandomIndex(b *testing.B) {
+	for _, n := range bigArraySizes {
+		data := []byte(bigArray(n))
+		idxs := randomIndexes(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for _, a := range randomAccessors {
+				b.Run(a.name, func(b *testing.B) {
+					var prepared interface{}
+					if a.prepare != nil {
+						prepared = a.prepare(data)
+					}
+					b.ReportAllocs()
+					b.ResetTimer()
+					defer reportMetrics(b)()
+					for j := 0; j < b.N; j++ {
+						i := idxs[j%len(idxs)]
+						if a.get(data, prepared, i) != int64(i) {
+							b.Fatal("did not find the value")
+						}
+					}
+				})
+			}
+		})
+	}
+}
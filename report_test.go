@@ -0,0 +1,137 @@
+package gjson_benchmarks
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var reportPath = flag.String("report", "",
+	"append result tables as markdown to this file")
+
+// table is a small result table that is written to the test log as aligned
+// text, and optionally appended to the -report file as markdown.
+type table struct {
+	title  string
+	header []string
+	rows   [][]string
+	noisy  bool
+}
+
+func newTable(title string, header ...string) *table {
+	return &table{title: title, header: header}
+}
+
+func (t *table) add(cols ...string) {
+	t.rows = append(t.rows, cols)
+}
+
+// mark flags cell as unreliable when cv, the coefficient of variation
+// of its repetitions, is above the -noise threshold.
+func (t *table) mark(cell string, cv float64) string {
+	if cv <= *noiseLimit {
+		return cell
+	}
+	t.noisy = true
+	return cell + " !"
+}
+
+func (t *table) timing(s sample) string {
+	return t.mark(roundDuration(s.mean), s.cv)
+}
+
+func (t *table) footnote() string {
+	if !t.noisy {
+		return ""
+	}
+	return fmt.Sprintf("! unreliable: varied by more than %.0f%% over %d repetitions\n",
+		*noiseLimit*100, repCount())
+}
+
+func (t *table) widths() []int {
+	w := make([]int, len(t.header))
+	for _, row := range append([][]string{t.header}, t.rows...) {
+		for i, col := range row {
+			if i < len(w) && len(col) > w[i] {
+				w[i] = len(col)
+			}
+		}
+	}
+	return w
+}
+
+func (t *table) String() string {
+	var sb strings.Builder
+	w := t.widths()
+	line := func(row []string) {
+		for i, col := range row {
+			if i > 0 {
+				sb.WriteString("  ")
+			}
+			fmt.Fprintf(&sb, "%-*s", w[i], col)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(t.title + "\n")
+	line(t.header)
+	for _, row := range t.rows {
+		line(row)
+	}
+	sb.WriteString(t.footnote())
+	return sb.String()
+}
+
+func (t *table) markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### %s\n\n", t.title)
+	sb.WriteString("| " + strings.Join(t.header, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(t.header)) + "\n")
+	for _, row := range t.rows {
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	if note := t.footnote(); note != "" {
+		sb.WriteString("\n" + note)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// publish logs the table and appends it to the -report file, if any.
+func publish(tb testing.TB, t *table) {
+	tb.Helper()
+	tb.Log("\n" + t.String())
+	if *reportPath == "" {
+		return
+	}
+	f, err := os.OpenFile(*reportPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(t.markdown()); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func yesno(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
+
+// roundDuration keeps about three significant digits for the tables.
+func roundDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d >= time.Microsecond:
+		return d.Round(time.Microsecond / 100).String()
+	}
+	return d.String()
+}
@@ -0,0 +1,117 @@
+package gjson_benchmarks
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	"github.com/tidwall/gjson"
+)
+
+// retainDocs is how many large documents each measurement reads, keeping
+// one value from each.
+const retainDocs = 64
+
+const retainPath = "statuses.50.user.screen_name"
+
+// retainCopyFactor bounds what a copying mode may retain, as a multiple of
+// the value's size. The copy is boxed in an interface and rounded up to a
+// size class, so it takes a few times the value itself.
+const retainCopyFactor = 8
+
+// retainModes extract one value from a freshly allocated document. The
+// aliasing modes keep a reference into the document; the others copy.
+var retainModes = []struct {
+	name     string
+	aliasing bool
+	extract  func(doc []byte) interface{}
+}{
+	{"gjson/GetBytes", false, func(doc []byte) interface{} {
+		return gjson.GetBytes(doc, retainPath).Str
+	}},
+	{"gjson/Get", true, func(doc []byte) interface{} {
+		return gjson.Get(string(doc), retainPath).Str
+	}},
+	{"gjson/Get+copy", false, func(doc []byte) interface{} {
+		return string([]byte(gjson.Get(string(doc), retainPath).Str))
+	}},
+	{"gjson/unsafe", true, func(doc []byte) interface{} {
+		return gjson.Get(bytesToString(doc), retainPath).Str
+	}},
+	{"jsonparser", true, func(doc []byte) interface{} {
+		value, _, _, _ := jsonparser.Get(doc, jsonparserKeys(retainPath)...)
+		return value
+	}},
+	{"jsonparser+copy", false, func(doc []byte) interface{} {
+		value, _, _, _ := jsonparser.Get(doc, jsonparserKeys(retainPath)...)
+		return append([]byte(nil), value...)
+	}},
+}
+
+func heapAlloc() uint64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+// retainedPerValue reads retainDocs documents, keeps one value of each
+// and returns the heap still in use per value after a collection.
+func retainedPerValue(extract func(doc []byte) interface{}) float64 {
+	kept := make([]interface{}, retainDocs)
+	before := heapAlloc()
+	for i := range kept {
+		kept[i] = extract([]byte(twitterLarge))
+	}
+	after := heapAlloc()
+	runtime.KeepAlive(kept)
+	if after < before {
+		return 0
+	}
+	return float64(after-before) / retainDocs
+}
+
+func TestRetainedMemory(t *testing.T) {
+	want := gjson.Get(twitterLarge, retainPath).Str
+	tbl := newTable("Heap retained per value kept from a "+
+		strconv.Itoa(len(twitterLarge)/1024)+"KB document",
+		"mode", "aliasing", "value", "retained")
+	for _, m := range retainModes {
+		var got string
+		switch v := m.extract([]byte(twitterLarge)).(type) {
+		case string:
+			got = v
+		case []byte:
+			got = string(v)
+		}
+		if got != want {
+			t.Fatalf("%s: got %q, want %q", m.name, got, want)
+		}
+		retained := retainedPerValue(m.extract)
+		switch {
+		case m.aliasing && retained < float64(len(twitterLarge))*0.9:
+			t.Errorf("%s: retained %.0fB per value, expected the %dB document",
+				m.name, retained, len(twitterLarge))
+		case !m.aliasing && retained > float64(retainCopyFactor*len(want)):
+			t.Errorf("%s: retained %.0fB per value, expected at most %dB for a %dB copy",
+				m.name, retained, retainCopyFactor*len(want), len(want))
+		}
+		tbl.add(m.name, yesno(m.aliasing), strconv.Itoa(len(want))+"B",
+			strconv.Itoa(int(retained))+"B")
+	}
+	publish(t, tbl)
+}
+
+func BenchmarkRetained(b *testing.B) {
+	for _, m := range retainModes {
+		b.Run(m.name, func(b *testing.B) {
+			var retained float64
+			defer reportMetrics(b)()
+			for i := 0; i < b.N; i++ {
+				retained = retainedPerValue(m.extract)
+			}
+			b.ReportMetric(retained, "retained-B/value")
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+var streamChunkSizes = []int{1, 512, 4096}
+
+// streamBufSize is the buffer that jsoniter reads the stream into.
+const streamBufSize = 512
+
+// chunkReader returns data at most size bytes per Read, like a socket or
+// pipe would. If sampleEvery is set, it also tracks the peak heap every
+// sampleEvery bytes.
+type chunkReader struct {
+	data        []byte
+	size        int
+	sampleEvery int
+	unsampled   int
+	peak        uint64
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := r.size
+	if n > len(p) {
+		n = len(p)
+	}
+	if n > len(r.data) {
+		n = len(r.data)
+	}
+	copy(p, r.data[:n])
+	r.data = r.data[n:]
+	if r.sampleEvery > 0 {
+		if r.unsampled += n; r.unsampled >= r.sampleEvery {
+			r.unsampled = 0
+			r.sample()
+		}
+	}
+	return n, nil
+}
+
+func (r *chunkReader) sample() {
+	if heap := readGC(gcSamples()).heap; heap > r.peak {
+		r.peak = heap
+	}
+}
+
+// streamGetters read the value at path from a stream.
+var streamGetters = []struct {
+	name string
+	get  func(r io.Reader, path string) ([]byte, error)
+}{
+	{"json/Decoder", decoderGet},
+	{"jsoniter/Parse", jsoniterStreamGet},
+	{"gjson/ReadAll", func(r io.Reader, path string) ([]byte, error) {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return gjsonGet(data, path)
+	}},
+}
+
+// jsoniterStreamGet skips through the stream down to path with a jsoniter
+// iterator and returns the raw value found there.
+func jsoniterStreamGet(r io.Reader, path string) ([]byte, error) {
+	iter := jsoniter.Parse(jsoniter.ConfigDefault, r, streamBufSize)
+	for _, key := range pathKeys(path) {
+		if !jsoniterChild(iter, key) {
+			if iter.Error != nil {
+				return nil, iter.Error
+			}
+			return nil, errNotFound
+		}
+	}
+	raw := iter.SkipAndReturnBytes()
+	return raw, iter.Error
+}
+
+// decoderGet walks the tokens of the stream down to path and decodes only
+// the value found there.
+func decoderGet(r io.Reader, path string) ([]byte, error) {
+	dec := json.NewDecoder(r)
+	for _, key := range pathKeys(path) {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		idx, isIndex := arrayIndex(key)
+		found := false
+		for i := 0; !found && dec.More(); i++ {
+			switch tok {
+			case json.Delim('{'):
+				name, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				found = name == key
+			case json.Delim('['):
+				found = isIndex && i == idx
+			default:
+				return nil, errNotFound
+			}
+			if !found {
+				if err := skipTokens(dec); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if !found {
+			return nil, errNotFound
+		}
+	}
+	var raw json.RawMessage
+	err := dec.Decode(&raw)
+	return raw, err
+}
+
+// streamPeak runs get once on a sampling reader and returns how far the
+// heap grew above where it was after a collection. The runtime counts
+// heap objects per span, so small values read as zero.
+func streamPeak(get func(r io.Reader, path string) ([]byte, error), data []byte, size int, path string) float64 {
+	runtime.GC()
+	base := readGC(gcSamples()).heap
+	r := &chunkReader{data: data, size: size, sampleEvery: 4096}
+	r.sample()
+	get(r, path)
+	r.sample()
+	if r.peak < base {
+		return 0
+	}
+	return float64(r.peak - base)
+}
+
+func TestStreamGet(t *testing.T) {
+	for _, c := range convertCases {
+		want := canonicalRaw([]byte(gjson.Get(c.json, c.path).Raw))
+		for _, size := range streamChunkSizes {
+			for _, g := range streamGetters {
+				r := &chunkReader{data: []byte(c.json), size: size}
+				raw, err := g.get(r, c.path)
+				if err != nil {
+					if c.name == "basic" {
+						// malformed before the path for some decoders
+						continue
+					}
+					t.Fatalf("%s: %d: %s: %v", c.name, size, g.name, err)
+				}
+				if got := canonicalRaw(raw); got != want {
+					t.Fatalf("%s: %d: %s: got %s, want %s", c.name, size, g.name, got, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkStream(b *testing.B) {
+	for _, c := range convertCases {
+		data := []byte(c.json)
+		b.Run(c.name, func(b *testing.B) {
+			for _, size := range streamChunkSizes {
+				b.Run(strconv.Itoa(size), func(b *testing.B) {
+					for _, g := range streamGetters {
+						b.Run(g.name, func(b *testing.B) {
+							skipUnreadable(b, func() error {
+								_, err := g.get(&chunkReader{data: data, size: size}, c.path)
+								return err
+							})
+							b.SetBytes(int64(len(data)))
+							b.ReportAllocs()
+							b.ResetTimer()
+							stopMetrics := reportMetrics(b)
+							for i := 0; i < b.N; i++ {
+								g.get(&chunkReader{data: data, size: size}, c.path)
+							}
+							stopMetrics()
+							b.ReportMetric(streamPeak(g.get, data, size, c.path), "peak-B")
+						})
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mailru/easyjson/jlexer"
+	fflib "github.com/pquerna/ffjson/fflib/v1"
+	"github.com/tidwall/gjson"
+)
+
+// stringKinds generate the JSON source of one string, quotes included.
+var stringKinds = []struct {
+	name string
+	gen  func(rng *rand.Rand, sb *strings.Builder)
+}{
+	{"escapes", func(rng *rand.Rand, sb *strings.Builder) {
+		// BMP code points, skipping the surrogate range
+		for i := 0; i < 64; i++ {
+			r := 0x20 + rng.Intn(0xD800-0x20)
+			if rng.Intn(2) == 0 {
+				fmt.Fprintf(sb, `\u%04x`, r)
+			} else {
+				fmt.Fprintf(sb, `\u%04X`, r)
+			}
+		}
+	}},
+	{"surrogates", func(rng *rand.Rand, sb *strings.Builder) {
+		for i := 0; i < 32; i++ {
+			// offset of an astral code point from U+10000
+			r := rng.Intn(0x100000)
+			fmt.Fprintf(sb, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		}
+	}},
+	{"quotes", func(rng *rand.Rand, sb *strings.Builder) {
+		escapes := []string{`\"`, `\\`, `\/`, `\n`, `\t`, `\r`, `\b`, `\f`}
+		for i := 0; i < 64; i++ {
+			if rng.Intn(2) == 0 {
+				sb.WriteString(escapes[rng.Intn(len(escapes))])
+			} else {
+				sb.WriteByte(byte('a' + rng.Intn(26)))
+			}
+		}
+	}},
+	{"utf8", func(rng *rand.Rand, sb *strings.Builder) {
+		// raw multi-byte text, no escapes
+		runes := []rune("日本語のテキストと絵文字😀🎉🚀ελληνικάкириллица")
+		for i := 0; i < 64; i++ {
+			sb.WriteRune(runes[rng.Intn(len(runes))])
+		}
+	}},
+	{"mixed", func(rng *rand.Rand, sb *strings.Builder) {
+		runes := []rune("plain ascii 日本語😀")
+		for i := 0; i < 64; i++ {
+			switch rng.Intn(4) {
+			case 0:
+				fmt.Fprintf(sb, `\u%04x`, 0x20+rng.Intn(0xD800-0x20))
+			case 1:
+				sb.WriteString(`😀`)
+			case 2:
+				sb.WriteString(`\"`)
+			default:
+				sb.WriteRune(runes[rng.Intn(len(runes))])
+			}
+		}
+	}},
+}
+
+// stringCorpus is {"strings":[...]} filled with one kind of string.
+type stringCorpus struct {
+	name string
+	json string
+	want []string // as decoded by encoding/json
+}
+
+var (
+	stringCorporaOnce sync.Once
+	stringCorporaList []stringCorpus
+)
+
+func stringCorpora() []stringCorpus {
+	stringCorporaOnce.Do(func() {
+		for i, kind := range stringKinds {
+			rng := rand.New(rand.NewSource(int64(i)))
+			var sb strings.Builder
+			sb.WriteString(`{"strings":[`)
+			for j := 0; j < 2000; j++ {
+				if j > 0 {
+					sb.WriteByte(',')
+				}
+				sb.WriteByte('"')
+				kind.gen(rng, &sb)
+				sb.WriteByte('"')
+			}
+			sb.WriteString(`]}`)
+			var v struct{ Strings []string }
+			if err := json.Unmarshal([]byte(sb.String()), &v); err != nil {
+				panic(err)
+			}
+			stringCorporaList = append(stringCorporaList,
+				stringCorpus{kind.name, sb.String(), v.Strings})
+		}
+	})
+	return stringCorporaList
+}
+
+// stringGetters append every decoded string in the corpus to out.
+var stringGetters = []struct {
+	name string
+	get  func(data []byte, out []string) ([]string, error)
+}{
+	{"gjson", func(data []byte, out []string) ([]string, error) {
+		gjson.GetBytes(data, "strings").ForEach(func(_, value gjson.Result) bool {
+			out = append(out, value.String())
+			return true
+		})
+		return out, nil
+	}},
+	{"encoding/json", func(data []byte, out []string) ([]string, error) {
+		var v struct {
+			Strings []string `json:"strings"`
+		}
+		err := json.Unmarshal(data, &v)
+		return append(out, v.Strings...), err
+	}},
+	{"ffjson", func(data []byte, out []string) ([]string, error) {
+		l := fflib.NewFFLexer(data)
+		tok := l.Scan()
+		tok, err := ffjsonChild(l, tok, "strings")
+		if err != nil || tok != fflib.FFTok_left_brace {
+			return out, fmt.Errorf("ffjson: strings not found")
+		}
+		for {
+			switch l.Scan() {
+			case fflib.FFTok_string:
+				out = append(out, l.Output.String())
+			case fflib.FFTok_comma:
+			case fflib.FFTok_right_brace:
+				return out, nil
+			default:
+				return out, ffjsonErr(l)
+			}
+		}
+	}},
+	{"easyjson", func(data []byte, out []string) ([]string, error) {
+		l := &jlexer.Lexer{Data: data}
+		easyjsonObject(l, func(key string) {
+			if key != "strings" {
+				l.SkipRecursive()
+				return
+			}
+			l.Delim('[')
+			for !l.IsDelim(']') {
+				out = append(out, l.String())
+				l.WantComma()
+			}
+			l.Delim(']')
+		})
+		return out, l.Error()
+	}},
+	{"jsonparser", func(data []byte, out []string) ([]string, error) {
+		var serr error
+		_, err := jsonparser.ArrayEach(data,
+			func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+				s, err := jsonparser.ParseString(value)
+				if err != nil && serr == nil {
+					serr = err
+				}
+				out = append(out, s)
+			}, "strings")
+		if err == nil {
+			err = serr
+		}
+		return out, err
+	}},
+	{"jsoniter", func(data []byte, out []string) ([]string, error) {
+		iter := jsoniter.ConfigDefault.BorrowIterator(data)
+		defer jsoniter.ConfigDefault.ReturnIterator(iter)
+		if jsoniterChild(iter, "strings") {
+			for iter.ReadArray() {
+				out = append(out, iter.ReadString())
+			}
+		}
+		return out, iter.Error
+	}},
+}
+
+func countSameStrings(want, got []string) (int, string) {
+	var same int
+	var first string
+	for i := range want {
+		if i < len(got) && got[i] == want[i] {
+			same++
+		} else if first == "" {
+			if i < len(got) {
+				first = fmt.Sprintf("#%d: %+q != %+q", i, got[i], want[i])
+			} else {
+				first = fmt.Sprintf("#%d: missing", i)
+			}
+		}
+	}
+	return same, first
+}
+
+func TestUnescape(t *testing.T) {
+	tbl := newTable("Decoded strings matching encoding/json",
+		"corpus", "getter", "same", "first difference")
+	for _, sc := range stringCorpora() {
+		for _, g := range stringGetters {
+			out, err := g.get([]byte(sc.json), nil)
+			if err != nil {
+				t.Errorf("%s: %s: %v", sc.name, g.name, err)
+			}
+			same, first := countSameStrings(sc.want, out)
+			if same != len(sc.want) {
+				t.Errorf("%s: %s: %d/%d strings match encoding/json, first difference %s",
+					sc.name, g.name, same, len(sc.want), first)
+			}
+			if len(first) > 60 {
+				first = first[:60] + "..."
+			}
+			tbl.add(sc.name, g.name, fmt.Sprintf("%d/%d", same, len(sc.want)), first)
+		}
+	}
+	publish(t, tbl)
+}
+
+func BenchmarkUnescape(b *testing.B) {
+	for _, sc := range stringCorpora() {
+		data := []byte(sc.json)
+		b.Run(sc.name, func(b *testing.B) {
+			for _, g := range stringGetters {
+				b.Run(g.name, func(b *testing.B) {
+					var out []string
+					var err error
+					b.SetBytes(int64(len(data)))
+					b.ReportAllocs()
+					stopMetrics := reportMetrics(b)
+					for i := 0; i < b.N; i++ {
+						if out, err = g.get(data, out[:0]); err != nil {
+							b.Fatal(err)
+						}
+					}
+					stopMetrics()
+					same, _ := countSameStrings(sc.want, out)
+					b.ReportMetric(float64(same)/float64(len(sc.want)), "same")
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+var validCorpora = []string{"example", "twitterLarge", "basic"}
+
+var validators = []struct {
+	name  string
+	valid func(data []byte) bool
+}{
+	{"gjson", gjson.ValidBytes},
+	{"encoding/json", json.Valid},
+	{"jsoniter", jsoniter.Valid},
+	{"jsonparser", func(data []byte) bool { return jsonparserWalk(data) == nil }},
+}
+
+// jsonparserWalk visits every value in the document. jsonparser has no
+// validator, so this is the closest equivalent.
+func jsonparserWalk(data []byte) error {
+	value, typ, _, err := jsonparser.Get(data)
+	if err != nil {
+		return err
+	}
+	return jsonparserWalkValue(value, typ)
+}
+
+func jsonparserWalkValue(value []byte, typ jsonparser.ValueType) error {
+	switch typ {
+	case jsonparser.Object:
+		return jsonparser.ObjectEach(value,
+			func(key, value []byte, typ jsonparser.ValueType, _ int) error {
+				return jsonparserWalkValue(value, typ)
+			})
+	case jsonparser.Array:
+		var werr error
+		_, err := jsonparser.ArrayEach(value,
+			func(value []byte, typ jsonparser.ValueType, _ int, err error) {
+				if werr == nil {
+					werr = err
+				}
+				if werr == nil {
+					werr = jsonparserWalkValue(value, typ)
+				}
+			})
+		if err != nil {
+			return err
+		}
+		return werr
+	case jsonparser.String:
+		_, err := jsonparser.ParseString(value)
+		return err
+	case jsonparser.Number:
+		_, err := jsonparser.ParseFloat(value)
+		return err
+	case jsonparser.Boolean:
+		_, err := jsonparser.ParseBoolean(value)
+		return err
+	case jsonparser.Null:
+		return nil
+	}
+	return errors.New("unknown value type")
+}
+
+func TestValidAgreement(t *testing.T) {
+	header := []string{"corpus"}
+	for _, v := range validators {
+		header = append(header, v.name)
+	}
+	header = append(header, "agree")
+	tbl := newTable("Validation verdicts", header...)
+	for _, name := range validCorpora {
+		data := []byte(lookupCorpus(name).json)
+		ref := json.Valid(data)
+		row := []string{name}
+		agree := true
+		for _, v := range validators {
+			ok := v.valid(data)
+			row = append(row, yesno(ok))
+			if ok != ref {
+				agree = false
+			}
+		}
+		tbl.add(append(row, yesno(agree))...)
+		if ref && !agree {
+			t.Errorf("%s: a validator rejected a well-formed document", name)
+		}
+	}
+	publish(t, tbl)
+}
+
+func BenchmarkValid(b *testing.B) {
+	for _, name := range validCorpora {
+		data := []byte(lookupCorpus(name).json)
+		ref := json.Valid(data)
+		b.Run(name, func(b *testing.B) {
+			for _, v := range validators {
+				b.Run(v.name, func(b *testing.B) {
+					var ok bool
+					b.SetBytes(int64(len(data)))
+					b.ReportAllocs()
+					b.ResetTimer()
+					stopMetrics := reportMetrics(b)
+					for i := 0; i < b.N; i++ {
+						ok = v.valid(data)
+					}
+					stopMetrics()
+					// 1 when this library agrees with encoding/json
+					if ok == ref {
+						b.ReportMetric(1, "agree")
+					} else {
+						b.ReportMetric(0, "agree")
+					}
+				})
+			}
+		})
+	}
+}
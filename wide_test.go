@@ -0,0 +1,188 @@
+package gjson_benchmarks
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buger/jsonparser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+var wideEnabled = flag.Bool("wide", false,
+	"report how many lookups in objects of up to a million keys it takes for a map to pay off")
+
+var wideSizes = []int{10000, 100000, 1000000}
+
+var (
+	wideMu      sync.Mutex
+	wideObjects = map[int]string{}
+)
+
+// wideObject returns a flat object of n feature flags, generated on first
+// use and kept for the rest of the run.
+func wideObject(n int) string {
+	wideMu.Lock()
+	defer wideMu.Unlock()
+	if s, ok := wideObjects[n]; ok {
+		return s
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `"flag_%d":%d`, i, i)
+	}
+	sb.WriteByte('}')
+	wideObjects[n] = sb.String()
+	return wideObjects[n]
+}
+
+// widePositions place the target key at the start, middle and end.
+var widePositions = []struct {
+	name string
+	pos  func(n int) int
+}{
+	{"first", func(n int) int { return 0 }},
+	{"middle", func(n int) int { return n / 2 }},
+	{"last", func(n int) int { return n - 1 }},
+}
+
+func wideKey(i int) string { return "flag_" + strconv.Itoa(i) }
+
+// wideScanners look up one key by scanning the document.
+var wideScanners = []struct {
+	name string
+	get  func(data []byte, key string) int64
+}{
+	{"gjson", func(data []byte, key string) int64 {
+		return gjson.GetBytes(data, key).Int()
+	}},
+	{"jsonparser", func(data []byte, key string) int64 {
+		n, _ := jsonparser.GetInt(data, key)
+		return n
+	}},
+	{"jsoniter", func(data []byte, key string) int64 {
+		iter := jsoniter.ConfigDefault.BorrowIterator(data)
+		defer jsoniter.ConfigDefault.ReturnIterator(iter)
+		for k := iter.ReadObject(); k != ""; k = iter.ReadObject() {
+			if k == key {
+				return iter.ReadInt64()
+			}
+			iter.Skip()
+		}
+		return 0
+	}},
+}
+
+// wideMaps decode the whole object into a map, which later lookups use.
+var wideMaps = []struct {
+	name   string
+	build  func(data []byte) interface{}
+	lookup func(m interface{}, key string) int64
+}{
+	{"json/map", func(data []byte) interface{} {
+		var m map[string]interface{}
+		json.Unmarshal(data, &m)
+		return m
+	}, func(m interface{}, key string) int64 {
+		f, _ := m.(map[string]interface{})[key].(float64)
+		return int64(f)
+	}},
+	{"gjson/map", func(data []byte) interface{} {
+		return gjson.ParseBytes(data).Map()
+	}, func(m interface{}, key string) int64 {
+		return m.(map[string]gjson.Result)[key].Int()
+	}},
+}
+
+func BenchmarkWideObject(b *testing.B) {
+	for _, n := range wideSizes {
+		data := []byte(wideObject(n))
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for _, p := range widePositions {
+				i := p.pos(n)
+				key := wideKey(i)
+				b.Run(p.name, func(b *testing.B) {
+					for _, s := range wideScanners {
+						b.Run(s.name, func(b *testing.B) {
+							b.ReportAllocs()
+							defer reportMetrics(b)()
+							for j := 0; j < b.N; j++ {
+								if s.get(data, key) != int64(i) {
+									b.Fatal("did not find the value")
+								}
+							}
+						})
+					}
+					for _, m := range wideMaps {
+						b.Run(m.name, func(b *testing.B) {
+							b.ReportAllocs()
+							defer reportMetrics(b)()
+							for j := 0; j < b.N; j++ {
+								if m.lookup(m.build(data), key) != int64(i) {
+									b.Fatal("did not find the value")
+								}
+							}
+						})
+						b.Run(m.name+"/lookup", func(b *testing.B) {
+							built := m.build(data)
+							b.ReportAllocs()
+							b.ResetTimer()
+							defer reportMetrics(b)()
+							for j := 0; j < b.N; j++ {
+								if m.lookup(built, key) != int64(i) {
+									b.Fatal("did not find the value")
+								}
+							}
+						})
+					}
+				})
+			}
+		})
+	}
+}
+
+// breakEven is the number of lookups after which building a map first is
+// cheaper than scanning for every lookup.
+func breakEven(build, lookup, scan time.Duration) string {
+	if scan <= lookup {
+		return "never"
+	}
+	return strconv.Itoa(int(math.Ceil(float64(build) / float64(scan-lookup))))
+}
+
+func TestWideObjectBreakEven(t *testing.T) {
+	if !*wideEnabled {
+		t.Skip("enable with -wide")
+	}
+	tbl := newTable("Lookups before building a map beats scanning",
+		"keys", "position", "scanner", "scan", "map", "build", "lookup", "break-even")
+	for _, n := range wideSizes {
+		data := []byte(wideObject(n))
+		for _, m := range wideMaps {
+			var built interface{}
+			build := timePerOp(50*time.Millisecond, func() { built = m.build(data) })
+			for _, p := range widePositions {
+				key := wideKey(p.pos(n))
+				lookup := timePerOp(10*time.Millisecond, func() { m.lookup(built, key) })
+				for _, s := range wideScanners {
+					scan := timePerOp(50*time.Millisecond, func() { s.get(data, key) })
+					tbl.add(strconv.Itoa(n), p.name, s.name, tbl.timing(scan),
+						m.name, tbl.timing(build), tbl.timing(lookup),
+						breakEven(build.mean, lookup.mean, scan.mean))
+				}
+			}
+		}
+	}
+	publish(t, tbl)
+}